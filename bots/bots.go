@@ -0,0 +1,203 @@
+package bots
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"shooter/game"
+)
+
+// Difficulty of a computer-controlled tank
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Normal
+	Hard
+)
+
+// ParseDifficulty turns "easy", "normal" or "hard" into a Difficulty
+func ParseDifficulty(name string) (Difficulty, bool) {
+	switch name {
+	case "easy":
+		return Easy, true
+	case "normal", "":
+		return Normal, true
+	case "hard":
+		return Hard, true
+	}
+	return Normal, false
+}
+
+// skill holds the knobs each difficulty turns
+type skill struct {
+	reaction  int     // Frames between decisions
+	aimError  float64 // Max random aim error in radians
+	lead      float64 // How much of the target's motion to lead (0..1)
+	dodge     bool    // Sidestep incoming bullets
+	sight     float64 // Distance at which targets are noticed
+	keepAway  float64 // Preferred distance to the target
+	fireRange float64 // Only shoot within this distance
+}
+
+var skills = map[Difficulty]skill{
+	Easy:   {reaction: 20, aimError: 0.35, lead: 0, dodge: false, sight: 250, keepAway: 120, fireRange: 200},
+	Normal: {reaction: 10, aimError: 0.15, lead: 0.5, dodge: true, sight: 400, keepAway: 150, fireRange: 300},
+	Hard:   {reaction: 4, aimError: 0.04, lead: 1, dodge: true, sight: 1000, keepAway: 180, fireRange: 450},
+}
+
+// Bot drives one tank through the same PlayerInput path as a human:
+// it wanders, chases the nearest enemy, aims with lead and dodges bullets.
+type Bot struct {
+	Difficulty Difficulty
+
+	rng      *rand.Rand
+	frame    int
+	decision game.PlayerInput
+	wanderX  float64
+	wanderY  float64
+	seen     map[string][2]float64 // Last position of each player, to estimate velocity
+}
+
+// New creates a bot, a zero seed picks one from the clock
+func New(difficulty Difficulty, seed int64) *Bot {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Bot{
+		Difficulty: difficulty,
+		rng:        rand.New(rand.NewSource(seed)),
+		wanderX:    -1,
+		seen:       make(map[string][2]float64),
+	}
+}
+
+// NextInput implements game.Controller
+func (b *Bot) NextInput(g *game.Game, self *game.Player) game.PlayerInput {
+	s := skills[b.Difficulty]
+	velocities := b.track(g)
+
+	b.frame++
+	if b.frame%s.reaction != 1 && s.reaction > 1 {
+		return b.drive(g, self) // Still reacting to the last thing we saw
+	}
+
+	dodging := false
+	if s.dodge {
+		b.decision, dodging = b.dodge(g, self)
+	}
+	if !dodging {
+		if target := nearestEnemy(g, self, s.sight); target != nil {
+			b.decision = b.attack(self, target, velocities[target.ID], s)
+		} else {
+			b.decision = b.wander(self)
+		}
+	}
+	return b.drive(g, self)
+}
+
+// drive steers towards the direction decided on with the match's
+// movement model, every frame since a tank's hull keeps turning
+func (b *Bot) drive(g *game.Game, self *game.Player) game.PlayerInput {
+	input := b.decision
+	input.MoveX, input.MoveY = g.Steer(self, input.MoveX, input.MoveY)
+	return input
+}
+
+// track estimates every player's velocity from their last position
+func (b *Bot) track(g *game.Game) map[string][2]float64 {
+	velocities := make(map[string][2]float64, len(g.Players))
+	for id, p := range g.Players {
+		if last, ok := b.seen[id]; ok {
+			velocities[id] = [2]float64{p.X - last[0], p.Y - last[1]}
+		}
+		b.seen[id] = [2]float64{p.X, p.Y}
+	}
+	return velocities
+}
+
+// nearestEnemy finds the closest player still in the match, teammates aside
+func nearestEnemy(g *game.Game, self *game.Player, sight float64) *game.Player {
+	var nearest *game.Player
+	best := sight
+	for id, p := range g.Players {
+		if id == self.ID || p.Eliminated() || g.Teammates(self.ID, id) {
+			continue
+		}
+		if d := math.Hypot(p.X-self.X, p.Y-self.Y); d < best {
+			nearest, best = p, d
+		}
+	}
+	return nearest
+}
+
+// attack aims at where the target will be when a bullet gets there, while
+// closing in to its preferred distance or circling the target
+func (b *Bot) attack(self, target *game.Player, velocity [2]float64, s skill) game.PlayerInput {
+	dx, dy := target.X-self.X, target.Y-self.Y
+	dist := math.Hypot(dx, dy)
+
+	// Lead the target by its velocity over the bullet's flight time
+	flight := dist / game.WeaponByID(self.Weapon).Speed
+	dx += velocity[0] * flight * s.lead
+	dy += velocity[1] * flight * s.lead
+
+	aim := math.Atan2(dy, dx) + (b.rng.Float64()*2-1)*s.aimError
+	input := game.PlayerInput{Aim: aim, Fire: dist < s.fireRange}
+
+	toTarget := math.Atan2(target.Y-self.Y, target.X-self.X)
+	if dist > s.keepAway {
+		input.MoveX, input.MoveY = math.Cos(toTarget), math.Sin(toTarget)
+	} else {
+		// Close enough: circle the target instead of ramming it
+		input.MoveX, input.MoveY = math.Cos(toTarget+math.Pi/2), math.Sin(toTarget+math.Pi/2)
+	}
+	return input
+}
+
+// dodge sidesteps the first bullet that is about to hit us
+func (b *Bot) dodge(g *game.Game, self *game.Player) (game.PlayerInput, bool) {
+	cx, cy := self.X, self.Y
+	for _, bullet := range g.Bullets {
+		if !bullet.Active || bullet.OwnerID == self.ID {
+			continue
+		}
+		vx, vy := bullet.Velocity()
+		speed2 := vx*vx + vy*vy
+		if speed2 == 0 {
+			continue
+		}
+
+		// Closest approach of the bullet to our center within the next second
+		rx, ry := cx-bullet.X, cy-bullet.Y
+		t := (rx*vx + ry*vy) / speed2
+		if t < 0 || t > 60 {
+			continue
+		}
+		missX, missY := rx-vx*t, ry-vy*t
+		if math.Hypot(missX, missY) > game.PlayerSize*1.5 {
+			continue
+		}
+
+		// Move perpendicular to the bullet, away from its path
+		px, py := -vy, vx
+		if px*missX+py*missY < 0 {
+			px, py = -px, -py
+		}
+		n := math.Hypot(px, py)
+		return game.PlayerInput{MoveX: px / n, MoveY: py / n, Aim: self.TurretAngle}, true
+	}
+	return game.PlayerInput{}, false
+}
+
+// wander drives towards random points in the arena
+func (b *Bot) wander(self *game.Player) game.PlayerInput {
+	if b.wanderX < 0 || math.Hypot(b.wanderX-self.X, b.wanderY-self.Y) < game.PlayerSize {
+		b.wanderX = b.rng.Float64() * (game.ScreenWidth - game.PlayerSize)
+		b.wanderY = b.rng.Float64() * (game.ScreenHeight - game.PlayerSize)
+	}
+	dx, dy := b.wanderX-self.X, b.wanderY-self.Y
+	n := math.Hypot(dx, dy)
+	return game.PlayerInput{MoveX: dx / n, MoveY: dy / n, Aim: math.Atan2(dy, dx)}
+}
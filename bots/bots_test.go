@@ -0,0 +1,94 @@
+package bots_test
+
+import (
+	"math"
+	"testing"
+
+	"shooter/bots"
+	"shooter/game"
+)
+
+// ** Test Bot Chases And Shoots**
+func TestBotChasesNearestEnemy(t *testing.T) {
+	g := &game.Game{Players: make(map[string]*game.Player)}
+	self := &game.Player{ID: "bot", X: 100, Y: 100, Health: game.MaxHealth}
+	g.Players["bot"] = self
+	g.Players["near"] = &game.Player{ID: "near", X: 250, Y: 100, Health: game.MaxHealth}
+	g.Players["far"] = &game.Player{ID: "far", X: 100, Y: 550, Health: game.MaxHealth}
+
+	bot := bots.New(bots.Hard, 1)
+	input := bot.NextInput(g, self)
+
+	if !input.Fire {
+		t.Errorf("Expected the bot to fire at a target in range")
+	}
+	if math.Abs(input.Aim) > 0.1 {
+		t.Errorf("Expected the bot to aim at the nearest enemy, got angle %f", input.Aim)
+	}
+}
+
+// ** Test Bot Dodges**
+func TestBotDodgesIncomingBullet(t *testing.T) {
+	g := &game.Game{Players: make(map[string]*game.Player)}
+	self := &game.Player{ID: "bot", X: 300, Y: 300, Health: game.MaxHealth}
+	g.Players["bot"] = self
+
+	// A bullet flying straight at us from the left
+	g.AddBulletFromPeer(game.BulletMessage{ID: 1, OwnerID: "enemy", X: 200, Y: 310, VX: game.BulletSpeed})
+
+	input := bots.New(bots.Normal, 1).NextInput(g, self)
+	if input.Fire || math.Abs(input.MoveY) < 0.9 {
+		t.Errorf("Expected the bot to sidestep vertically, got %+v", input)
+	}
+}
+
+// ** Test Bots In A Client**
+func TestBotsShareTheMesh(t *testing.T) {
+	var sent []interface{}
+	g := &game.Game{
+		Players:    make(map[string]*game.Player),
+		SendUpdate: func(msg interface{}) { sent = append(sent, msg) },
+	}
+	g.AddBot("bot-1", bots.New(bots.Easy, 1))
+	g.UpdateBots()
+
+	moved := false
+	for _, msg := range sent {
+		if move, ok := msg.(game.MovementMessage); ok && move.ID == "bot-1" {
+			moved = true
+		}
+	}
+	if !moved {
+		t.Errorf("Expected the bot's movement to be broadcast")
+	}
+}
+
+// ** Test Bots On An Authority**
+func TestBotsTakeTheInputPath(t *testing.T) {
+	g := &game.Game{Players: make(map[string]*game.Player), Authoritative: true}
+	g.AddBot("bot-1", steady{})
+	bot := g.Players["bot-1"]
+	bot.X, bot.Y = 300, 300
+
+	// Like a client, a bot is held to the per-tick input budget
+	for i := 0; i < game.MaxInputsPerTick+2; i++ {
+		g.UpdateBots()
+	}
+	if want := float64(300 + game.MaxInputsPerTick*game.PlayerSpeed); math.Abs(bot.X-want) > 1e-9 {
+		t.Errorf("Expected the bot to move %d steps this tick to x=%v, got %v", game.MaxInputsPerTick, want, bot.X)
+	}
+
+	// The next tick refills the budget
+	g.Tick()
+	g.UpdateBots()
+	if want := float64(300 + (game.MaxInputsPerTick+1)*game.PlayerSpeed); math.Abs(bot.X-want) > 1e-9 {
+		t.Errorf("Expected the bot to move again after a tick to x=%v, got %v", want, bot.X)
+	}
+}
+
+// steady always drives right
+type steady struct{}
+
+func (steady) NextInput(g *game.Game, self *game.Player) game.PlayerInput {
+	return game.PlayerInput{MoveX: 1}
+}
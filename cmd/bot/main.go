@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"shooter/bots"
+	"shooter/game"
+	"shooter/peer"
+)
+
+// Headless bot peer: joins a match through discovery (or a
+// dedicated server) like a player would, driven by package bots
+func main() {
+	difficulty := flag.String("difficulty", "normal", "Bot skill: easy, normal or hard")
+	serverAddr := flag.String("server", "", "Connect to a dedicated server (host:port) instead of the peer mesh")
+	hostMode := flag.Bool("host", false, "Join a host-authoritative match")
+	matchFlags := game.RegisterMatchFlags(flag.CommandLine, 1) // The same rules as the other mesh peers
+	team := flag.Int("team", 0, "Team to join from 1, 0 to be put on the smallest team")
+	addrFlags := peer.AddrFlags(flag.CommandLine)
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Println("Usage: go run ./cmd/bot [flags] <port>")
+		os.Exit(1)
+	}
+	skill, ok := bots.ParseDifficulty(*difficulty)
+	if !ok {
+		fmt.Println("Unknown difficulty:", *difficulty)
+		os.Exit(1)
+	}
+	match, err := matchFlags.Config()
+	if err != nil {
+		fmt.Println("Invalid match settings:", err)
+		os.Exit(1)
+	}
+	if match.NeedsReferee() && *serverAddr == "" && !*hostMode {
+		fmt.Println("Invalid match settings: -mode", match.Mode, "needs -server or -host to keep score")
+		os.Exit(1)
+	}
+
+	addrs, err := addrFlags.Resolve(flag.Arg(0))
+	if err != nil {
+		fmt.Println("Error finding this machine's LAN address (set -advertise and -discovery):", err)
+		os.Exit(1)
+	}
+	playerAddr := addrs.Advertise
+	node := peer.NewNode(addrs.Options()...)
+
+	gameInstance := &game.Game{
+		LocalPlayerID:   playerAddr,
+		Players:         make(map[string]*game.Player),
+		SendUpdate:      node.Broadcast,
+		SendTo:          node.SendTo,
+		Client:          *serverAddr != "",
+		HostMode:        *hostMode && *serverAddr == "",
+		LocalController: bots.New(skill, 0),
+		Match:           match,
+		Team:            *team,
+	}
+	node.SetHandler(gameInstance)
+	node.HandleExit()
+
+	if *serverAddr != "" {
+		if err := node.Connect(*serverAddr); err != nil {
+			os.Exit(1)
+		}
+		node.Broadcast(game.JoinMessage{Type: "join", ID: playerAddr, Team: *team})
+	} else {
+		if err := node.Start(); err != nil {
+			fmt.Println("Error starting peer server:", err)
+			os.Exit(1)
+		}
+		node.Register()
+		for _, peerAddr := range node.Peers() {
+			go node.Connect(peerAddr)
+		}
+	}
+
+	fmt.Println("Bot", playerAddr, "playing on", *difficulty)
+	gameInstance.RunHeadless(nil)
+}
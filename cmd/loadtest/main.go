@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"shooter/discovery"
+	"shooter/game"
+	"shooter/peer"
+)
+
+// Load test: a discovery server and N headless peers in one process, all
+// running the real game simulation over real TCP connections in a full mesh
+var (
+	peerCount = flag.Int("peers", 10, "Number of simulated peers")
+	duration  = flag.Duration("duration", 30*time.Second, "How long to run once every peer has joined")
+	fireEvery = flag.Int("fire-every", 30, "Frames between shots of each scripted tank")
+)
+
+// loadMatch puts every tank on one team without friendly fire: bullets fly
+// but nobody is eliminated, so every peer keeps sending for the whole run
+// and the rates don't depend on who wins the fights
+var loadMatch = game.MatchConfig{Teams: 1}
+
+// stats collects what the run measured, shared by every simulated peer
+type stats struct {
+	sentAt sync.Map // Message as sent -> time.Time
+
+	mutex     sync.Mutex
+	latencies map[string][]time.Duration // By message type
+}
+
+func (s *stats) sent(data []byte) {
+	s.sentAt.Store(string(data), time.Now())
+}
+
+func (s *stats) received(data []byte) {
+	at, ok := s.sentAt.Load(string(bytes.TrimSpace(data)))
+	if !ok {
+		return
+	}
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(data, &envelope) != nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.latencies == nil {
+		s.latencies = make(map[string][]time.Duration)
+	}
+	s.latencies[envelope.Type] = append(s.latencies[envelope.Type], time.Since(at.(time.Time)))
+}
+
+// types returns the message types that had their latency measured
+func (s *stats) types() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	types := make([]string, 0, len(s.latencies))
+	for typ := range s.latencies {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// percentile returns the p-th percentile latency of one message type
+func (s *stats) percentile(typ string, p float64) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	latencies := s.latencies[typ]
+	if len(latencies) == 0 {
+		return 0
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return latencies[int(math.Min(float64(len(latencies)-1), p/100*float64(len(latencies))))]
+}
+
+// script drives a tank in a circle and fires at a fixed rate
+type script struct {
+	phase float64
+	frame int
+}
+
+func (s *script) NextInput(g *game.Game, self *game.Player) game.PlayerInput {
+	s.frame++
+	angle := s.phase + float64(s.frame)/60
+	moveX, moveY := g.Steer(self, math.Cos(angle), math.Sin(angle))
+	return game.PlayerInput{MoveX: moveX, MoveY: moveY, Aim: -angle, Fire: s.frame%*fireEvery == 0}
+}
+
+// simPeer is one headless player with its own peer node, counting what
+// goes through it
+type simPeer struct {
+	addr  string
+	game  *game.Game
+	node  *peer.Node
+	stats *stats
+
+	msgsOut, msgsIn   atomic.Int64
+	bytesOut, bytesIn atomic.Int64
+}
+
+func newSimPeer(index int, discoveryAddr string, st *stats) (*simPeer, error) {
+	p := &simPeer{stats: st}
+	p.node = peer.NewNode(
+		peer.WithListenAddr("127.0.0.1:0"),
+		peer.WithDiscovery(discoveryAddr),
+		peer.WithHandler(p),
+		peer.WithLogger(log.New(io.Discard, "", 0)),
+	)
+	if err := p.node.Start(); err != nil {
+		return nil, err
+	}
+	p.addr = p.node.Addr().String()
+	p.game = &game.Game{
+		LocalPlayerID:   p.addr,
+		Players:         make(map[string]*game.Player),
+		SendUpdate:      p.broadcast,
+		LocalController: &script{phase: float64(index)},
+		Match:           loadMatch,
+	}
+	return p, nil
+}
+
+// Deliver counts a message from the mesh and hands it to the game
+func (p *simPeer) Deliver(data []byte) {
+	p.msgsIn.Add(1)
+	p.bytesIn.Add(int64(len(data)))
+	p.stats.received(data)
+	p.game.Deliver(data)
+}
+
+// Disconnect passes a departed peer on to the game
+func (p *simPeer) Disconnect(id string) {
+	p.game.Disconnect(id)
+}
+
+func (p *simPeer) broadcast(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	p.stats.sent(data)
+
+	conns := int64(len(p.node.Connections()))
+	p.node.Broadcast(json.RawMessage(data))
+	p.msgsOut.Add(conns)
+	p.bytesOut.Add(conns * int64(len(data)))
+}
+
+func (p *simPeer) close() {
+	p.node.Close()
+}
+
+func main() {
+	flag.Parse()
+	goroutinesAtStart := runtime.NumGoroutine()
+
+	// Local discovery server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println("Error starting discovery server:", err)
+		return
+	}
+	defer listener.Close()
+	go discovery.NewServer().Serve(listener)
+
+	// Peers join one by one and connect to everyone already registered
+	st := &stats{}
+	peers := make([]*simPeer, 0, *peerCount)
+	stop := make(chan struct{})
+	for i := 0; i < *peerCount; i++ {
+		p, err := newSimPeer(i, listener.Addr().String(), st)
+		if err != nil {
+			fmt.Println("Error starting peer:", err)
+			return
+		}
+		for _, addr := range p.node.Peers() {
+			if err := p.node.Connect(addr); err != nil {
+				fmt.Println("Error connecting to peer:", addr, err)
+			}
+		}
+		p.node.Register()
+		peers = append(peers, p)
+		go p.game.RunHeadless(stop)
+	}
+	fmt.Printf("%d peers joined, running for %v...\n", len(peers), *duration)
+
+	// Sample goroutines while the match runs
+	peakGoroutines := runtime.NumGoroutine()
+	start := time.Now()
+	for time.Since(start) < *duration {
+		time.Sleep(100 * time.Millisecond)
+		if n := runtime.NumGoroutine(); n > peakGoroutines {
+			peakGoroutines = n
+		}
+	}
+	elapsed := time.Since(start).Seconds()
+	close(stop)
+	for _, p := range peers {
+		p.close()
+	}
+
+	report(peers, st, elapsed, goroutinesAtStart, peakGoroutines)
+}
+
+// report prints message rates, bandwidth, latency and goroutine counts
+func report(peers []*simPeer, st *stats, elapsed float64, goroutinesAtStart, peakGoroutines int) {
+	var totalOut, totalIn, upSum, downSum int64
+	var maxUp, maxDown float64
+	for _, p := range peers {
+		totalOut += p.msgsOut.Load()
+		totalIn += p.msgsIn.Load()
+		upSum += p.bytesOut.Load()
+		downSum += p.bytesIn.Load()
+		maxUp = math.Max(maxUp, float64(p.bytesOut.Load())/elapsed)
+		maxDown = math.Max(maxDown, float64(p.bytesIn.Load())/elapsed)
+	}
+	n := float64(len(peers))
+
+	fmt.Println()
+	fmt.Printf("Peers:              %d (%d mesh connections)\n", len(peers), len(peers)*(len(peers)-1)/2)
+	fmt.Printf("Messages sent:      %.0f/s total, %.0f/s per peer\n", float64(totalOut)/elapsed, float64(totalOut)/elapsed/n)
+	fmt.Printf("Messages received:  %.0f/s total, %.0f/s per peer\n", float64(totalIn)/elapsed, float64(totalIn)/elapsed/n)
+	fmt.Printf("Upload per peer:    %.1f KB/s avg, %.1f KB/s max\n", float64(upSum)/elapsed/n/1024, maxUp/1024)
+	fmt.Printf("Download per peer:  %.1f KB/s avg, %.1f KB/s max\n", float64(downSum)/elapsed/n/1024, maxDown/1024)
+	for _, typ := range st.types() {
+		fmt.Printf("%-20s p50 %v  p90 %v  p99 %v  max %v\n", "Latency ("+typ+"):",
+			st.percentile(typ, 50), st.percentile(typ, 90), st.percentile(typ, 99), st.percentile(typ, 100))
+	}
+	fmt.Printf("Goroutines:         %d at start, %d peak, %d at end\n", goroutinesAtStart, peakGoroutines, runtime.NumGoroutine())
+}
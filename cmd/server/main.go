@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"shooter/bots"
+	"shooter/game"
+)
+
+// Dedicated authoritative server: clients send inputs, the server runs the
+// only simulation and sends snapshots (as deltas against each client's last
+// ack), bullets and hits back to them
+var (
+	addr       = flag.String("addr", ":6000", "Address to accept clients on")
+	tickRate   = flag.Int("tick", 60, "Simulation ticks per second")
+	botCount   = flag.Int("bots", 0, "Computer-controlled tanks to fill the match with")
+	botSkill   = flag.String("difficulty", "normal", "Bot skill: easy, normal or hard")
+	matchFlags = game.RegisterMatchFlags(flag.CommandLine, 0)
+)
+
+// message received from a client, tagged with the connection it came from
+type clientMessage struct {
+	conn net.Conn
+	data []byte
+}
+
+type server struct {
+	game     *game.Game
+	incoming chan clientMessage
+	left     chan net.Conn
+
+	mutex   sync.Mutex
+	clients map[net.Conn]string // Connection -> player ID
+}
+
+func main() {
+	flag.Parse()
+	match, err := matchFlags.Config()
+	if err != nil {
+		fmt.Println("Invalid match settings:", err)
+		return
+	}
+	if match.Seed == 0 {
+		match.Seed = time.Now().UnixNano()
+	}
+
+	srv := &server{
+		incoming: make(chan clientMessage, 256),
+		left:     make(chan net.Conn, 16),
+		clients:  make(map[net.Conn]string),
+	}
+	srv.game = &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+		SendUpdate:    srv.broadcast,
+		SendTo:        srv.sendTo,
+		Match:         match,
+	}
+
+	skill, ok := bots.ParseDifficulty(*botSkill)
+	if !ok {
+		fmt.Println("Unknown difficulty:", *botSkill)
+		return
+	}
+	for i := 1; i <= *botCount; i++ {
+		srv.game.AddBot(fmt.Sprintf("bot-%d", i), bots.New(skill, 0))
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Println("Error starting server:", err)
+		return
+	}
+	fmt.Println("Authoritative server is running on", *addr)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go srv.acceptClients(listener)
+	srv.run(stop)
+
+	// Stop taking clients, then let the connected ones go
+	fmt.Println("Shutting down...")
+	listener.Close()
+	srv.closeClients()
+}
+
+func (s *server) acceptClients(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			fmt.Println("Connection error:", err)
+			continue
+		}
+
+		s.mutex.Lock()
+		s.clients[conn] = ""
+		s.mutex.Unlock()
+
+		fmt.Println("Client connected:", conn.RemoteAddr())
+		go s.readClient(conn)
+	}
+}
+
+// readClient forwards every message from a client to the simulation loop
+func (s *server) readClient(conn net.Conn) {
+	defer func() { s.left <- conn }()
+
+	decoder := json.NewDecoder(conn)
+	for {
+		var data json.RawMessage
+		if err := decoder.Decode(&data); err != nil {
+			fmt.Println("Client disconnected:", conn.RemoteAddr())
+			return
+		}
+		s.incoming <- clientMessage{conn: conn, data: data}
+	}
+}
+
+// run owns the simulation: it applies client messages and ticks at a fixed
+// rate until stop fires
+func (s *server) run(stop <-chan os.Signal) {
+	ticker := time.NewTicker(time.Second / time.Duration(*tickRate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+
+		case msg := <-s.incoming:
+			if s.accept(msg) {
+				s.game.HandleMessage(msg.data)
+			}
+
+		case conn := <-s.left:
+			s.mutex.Lock()
+			id := s.clients[conn]
+			delete(s.clients, conn)
+			s.mutex.Unlock()
+			conn.Close()
+
+			if id != "" {
+				s.game.RemovePlayer(id)
+				fmt.Println("Player left:", id)
+			}
+
+		case <-ticker.C:
+			s.game.UpdateBots()
+			snap := s.game.Tick()
+			for _, id := range s.playerIDs() {
+				s.sendTo(id, s.game.SnapshotFor(id, snap))
+			}
+		}
+	}
+}
+
+// closeClients disconnects every client
+func (s *server) closeClients() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.clients {
+		conn.Close()
+	}
+}
+
+// accept binds a connection to the first player ID it uses and rejects
+// messages that try to act for anyone else
+func (s *server) accept(msg clientMessage) bool {
+	var envelope struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(msg.data, &envelope) != nil || envelope.ID == "" {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id, known := s.clients[msg.conn]
+	if !known {
+		return false
+	}
+	if id == "" {
+		for _, other := range s.clients {
+			if other == envelope.ID {
+				return false // ID already taken by another connection
+			}
+		}
+		s.clients[msg.conn] = envelope.ID
+		return true
+	}
+	return id == envelope.ID
+}
+
+// playerIDs lists the players of every connected client
+func (s *server) playerIDs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var ids []string
+	for _, id := range s.clients {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// sendTo sends a message to the client controlling a player
+func (s *server) sendTo(id string, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		fmt.Println("Error encoding update:", err)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn, clientID := range s.clients {
+		if clientID == id {
+			if _, err := conn.Write(jsonData); err != nil {
+				fmt.Println("Error sending update:", err)
+			}
+		}
+	}
+}
+
+// broadcast sends a message to every connected client
+func (s *server) broadcast(data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		fmt.Println("Error encoding update:", err)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.clients {
+		if _, err := conn.Write(jsonData); err != nil {
+			fmt.Println("Error sending update:", err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package discovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Request structure from peers
+type Request struct {
+	Type string `json:"type"`
+	Addr string `json:"addr,omitempty"`
+}
+
+// Response structure to peers
+type Response struct {
+	Peers []string `json:"peers"`
+}
+
+// Server keeps the list of active peers, see discovery_server
+type Server struct {
+	peers map[string]bool // Store active peers
+	mutex sync.Mutex
+}
+
+// NewServer creates an empty discovery server
+func NewServer() *Server {
+	return &Server{peers: make(map[string]bool)}
+}
+
+// Serve answers peer requests until the listener is closed, other accept
+// errors (e.g. running out of file descriptors) are logged and skipped
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			fmt.Println("Connection error:", err)
+			continue
+		}
+		go s.handleConnection(conn)
+	}
+}
+
+// Peers returns the currently registered peers
+func (s *Server) Peers() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var peerList []string
+	for addr := range s.peers {
+		peerList = append(peerList, addr)
+	}
+	return peerList
+}
+
+func (s *Server) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	decoder := json.NewDecoder(conn)
+	var req Request
+	if err := decoder.Decode(&req); err != nil {
+		fmt.Println("Invalid request:", err)
+		return
+	}
+
+	switch req.Type {
+	case "register":
+		s.mutex.Lock()
+		s.peers[req.Addr] = true
+		s.mutex.Unlock()
+		fmt.Println("Registered peer:", req.Addr)
+
+	case "deregister": // Remove peer from active list
+		s.mutex.Lock()
+		delete(s.peers, req.Addr)
+		s.mutex.Unlock()
+		fmt.Println("Deregistered peer:", req.Addr)
+
+	case "get_peers":
+		encoder := json.NewEncoder(conn)
+		encoder.Encode(Response{Peers: s.Peers()})
+	}
+}
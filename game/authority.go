@@ -0,0 +1,409 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// MaxInputsPerTick limits how many inputs a client may apply per server
+// tick, so a modified client cannot move faster by sending more inputs
+const MaxInputsPerTick = 2
+
+// JoinMessage struct (sent by a client when it connects to a server)
+type JoinMessage struct {
+	Type string `json:"type"` // "join"
+	ID   string `json:"id"`
+	Team int    `json:"team,omitempty"` // Requested team, 0 to be auto-balanced
+}
+
+// InputMessage struct (sent by a client instead of movement when a server
+// is authoritative)
+type InputMessage struct {
+	Type string `json:"type"` // "input"
+	ID   string `json:"id"`
+	PlayerInput
+}
+
+// PlayerState is the authoritative state of one player in a snapshot
+type PlayerState struct {
+	ID         string  `json:"id"`
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	Angle      float64 `json:"angle"`
+	Turret     float64 `json:"turret"`
+	Speed      float64 `json:"speed,omitempty"` // Tank movement only
+	Weapon     string  `json:"weapon,omitempty"`
+	Health     int     `json:"health"`
+	Shield     int     `json:"shield,omitempty"`
+	Score      int     `json:"score,omitempty"`
+	Team       int     `json:"team,omitempty"`
+	Boost      int     `json:"boost,omitempty"` // Frames of speed boost left
+	Rapid      int     `json:"rapid,omitempty"` // Frames of rapid fire left
+	Seq        uint32  `json:"seq"` // Last input from this player applied
+	Eliminated bool    `json:"eliminated,omitempty"`
+}
+
+// SnapshotMessage struct (broadcast by the server every tick)
+type SnapshotMessage struct {
+	Type    string        `json:"type"`           // "snapshot"
+	Host    string        `json:"host,omitempty"` // Elected host that sent it (host mode)
+	Tick    uint32        `json:"tick"`
+	Match   MatchConfig   `json:"match"` // Rules the authority is running
+	Players []PlayerState `json:"players"`
+	Bullets []BulletState `json:"bullets,omitempty"`
+	Pickups []PickupState `json:"pickups,omitempty"`
+	Teams   []int         `json:"teams,omitempty"` // Hill points per team (king of the hill)
+}
+
+// HandleMessage decodes a message received from the network and applies it.
+// An authoritative game only accepts joins and inputs, never state claimed
+// by a client, and a client of an elected host only its snapshots. It must run on the goroutine that updates the game, network
+// goroutines hand messages over with Deliver instead.
+func (g *Game) HandleMessage(data []byte) {
+	var envelope struct {
+		Type string `json:"type"`
+		Host string `json:"host"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		fmt.Println("Error decoding message:", err)
+		return
+	}
+
+	// Any message from a peer proves it is still alive
+	if g.HostMode {
+		if senderTypes[envelope.Type] {
+			var sender struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(data, &sender); err != nil {
+				fmt.Println("Error decoding message:", err)
+				return
+			}
+			if envelope.Type == "spectate" {
+				g.watching(sender.ID)
+				return
+			}
+			g.heard(sender.ID)
+		}
+		g.heard(envelope.Host)
+	}
+
+	if g.Authoritative {
+		switch envelope.Type {
+		case "spectate":
+			// Nothing to spawn, the server only needs to know who to send snapshots to
+		case "join":
+			var msg JoinMessage
+			if json.Unmarshal(data, &msg) == nil {
+				g.AddPlayerOnTeam(msg.ID, msg.Team)
+			}
+		case "input":
+			var msg InputMessage
+			if json.Unmarshal(data, &msg) == nil {
+				g.ApplyRemoteInput(msg)
+			}
+		case "ack":
+			var msg AckMessage
+			if json.Unmarshal(data, &msg) == nil {
+				g.ackSnapshot(msg)
+			}
+		}
+		return
+	}
+
+	// Once a host is elected its snapshots are the only world state, so no
+	// other peer can set positions, health or pickups
+	if g.HostMode && g.HostID != "" && envelope.Type != "snapshot" && envelope.Type != "delta" {
+		return
+	}
+
+	switch envelope.Type {
+	case "move", "bullet", "hit", "pickup", "pickup_grant", "respawn":
+		g.Recorder.RecordRaw(RecordIn, data)
+	}
+
+	switch envelope.Type {
+	case "move": // Handle movement updates
+		var msg MovementMessage
+		if json.Unmarshal(data, &msg) == nil {
+			g.UpdatePlayerPosition(msg)
+		}
+	case "bullet": // Handle shooting updates
+		var msg BulletMessage
+		if json.Unmarshal(data, &msg) == nil {
+			g.AddBulletFromPeer(msg)
+		}
+	case "hit": // Handle hits confirmed by the victim or the server
+		var msg HitMessage
+		if json.Unmarshal(data, &msg) == nil {
+			g.ApplyHit(msg)
+		}
+	case "respawn": // Handle a mesh peer's tank coming back after elimination
+		var msg RespawnMessage
+		if json.Unmarshal(data, &msg) == nil {
+			g.ApplyRespawn(msg)
+		}
+	case "pickup": // Handle a mesh peer claiming a pickup
+		var msg PickupMessage
+		if json.Unmarshal(data, &msg) == nil {
+			g.ApplyPickupClaim(msg)
+		}
+	case "pickup_grant": // Handle the arbiter handing a pickup out
+		var msg PickupGrantMessage
+		if json.Unmarshal(data, &msg) == nil {
+			g.ApplyPickupGrant(msg)
+		}
+	case "snapshot": // Handle world state from the server
+		var msg SnapshotMessage
+		if g.acceptSnapshot(data) && json.Unmarshal(data, &msg) == nil {
+			g.Recorder.RecordRaw(RecordIn, data)
+			g.ApplySnapshot(msg)
+		}
+	case "delta": // Handle world changes from the server
+		var msg DeltaMessage
+		if g.acceptSnapshot(data) && json.Unmarshal(data, &msg) == nil {
+			g.Recorder.RecordRaw(RecordIn, data)
+			g.ApplyDelta(msg)
+		}
+	}
+}
+
+// sendInput forwards a local input to the server
+func (g *Game) sendInput(id string, input PlayerInput) {
+	if g.SendUpdate != nil {
+		g.SendUpdate(InputMessage{Type: "input", ID: id, PlayerInput: input})
+	}
+}
+
+// AddPlayer spawns a player on the authoritative simulation
+func (g *Game) AddPlayer(id string) {
+	g.AddPlayerOnTeam(id, 0)
+}
+
+// AddPlayerOnTeam spawns a player on the requested team, or on the
+// smallest team if it asks for none or for one the match doesn't have
+func (g *Game) AddPlayerOnTeam(id string, team int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if _, exists := g.Players[id]; exists || id == "" {
+		return
+	}
+	x, y := g.getRandomSpawn(g.Players)
+	g.Players[id] = &Player{ID: id, X: x, Y: y, Health: MaxHealth, Team: g.pickTeam(team)}
+	if g.lastHeard != nil {
+		g.lastHeard[id] = g.now()
+	}
+	fmt.Println("Player joined:", id)
+}
+
+// RemovePlayer drops a player immediately, e.g. when its client disconnects
+func (g *Game) RemovePlayer(id string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	delete(g.Players, id)
+	delete(g.lastInput, id)
+	delete(g.inputBudget, id)
+	delete(g.acked, id)
+}
+
+// ApplyRemoteInput validates a client input and applies it to that
+// client's player
+func (g *Game) ApplyRemoteInput(msg InputMessage) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	player, exists := g.Players[msg.ID]
+	if !exists || player.eliminated {
+		return
+	}
+
+	// Drop duplicated or replayed inputs, anything over the rate limit and
+	// nonsense, before acknowledging it as applied
+	if msg.Seq <= g.lastInput[msg.ID] || g.inputBudget[msg.ID] >= MaxInputsPerTick {
+		return
+	}
+	input := msg.PlayerInput
+	if math.IsNaN(input.MoveX) || math.IsNaN(input.MoveY) || math.IsNaN(input.Aim) || math.IsInf(input.Aim, 0) {
+		return
+	}
+	g.ackInput(msg.ID, msg.Seq)
+
+	// Never trust a client to move faster than PlayerSpeed
+	input.MoveX = math.Max(-1, math.Min(1, input.MoveX))
+	input.MoveY = math.Max(-1, math.Min(1, input.MoveY))
+	g.applyMovement(player, input)
+	switchWeapon(player, input.Weapon)
+
+	if input.Fire {
+		g.tryFire(player)
+	}
+}
+
+// ackInput records the last input applied for a player and counts it
+// against this tick's budget
+func (g *Game) ackInput(id string, seq uint32) {
+	if g.lastInput == nil {
+		g.lastInput = make(map[string]uint32)
+	}
+	if g.inputBudget == nil {
+		g.inputBudget = make(map[string]int)
+	}
+	g.lastInput[id] = seq
+	g.inputBudget[id]++
+}
+
+// Tick advances the authoritative simulation by one step and returns the
+// resulting world state
+func (g *Game) Tick() SnapshotMessage {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.tick++
+	if !g.matchOver() {
+		for _, player := range g.Players {
+			coolDown(player)
+		}
+		g.updateMatch()
+		g.updateShields()
+		g.updatePickups()
+		g.UpdateBullets()
+	}
+	g.updateRemovals()
+	g.inputBudget = make(map[string]int)
+
+	snap := g.snapshot()
+	g.remember(snap)
+	return snap
+}
+
+// snapshot captures every player and bullet in a stable order
+func (g *Game) snapshot() SnapshotMessage {
+	snap := SnapshotMessage{Type: "snapshot", Tick: g.tick, Match: g.Match}
+	for _, p := range g.Players {
+		snap.Players = append(snap.Players, PlayerState{
+			ID:         p.ID,
+			X:          p.X,
+			Y:          p.Y,
+			Angle:      p.Angle,
+			Turret:     p.TurretAngle,
+			Speed:      p.Speed,
+			Weapon:     p.Weapon,
+			Health:     p.Health,
+			Shield:     p.Shield,
+			Score:      p.Score,
+			Team:       p.Team,
+			Boost:      p.boost,
+			Rapid:      p.rapid,
+			Seq:        g.lastInput[p.ID],
+			Eliminated: p.eliminated,
+		})
+	}
+	for _, b := range g.Bullets {
+		if b.Active {
+			snap.Bullets = append(snap.Bullets, BulletState{
+				ID:      b.ID,
+				OwnerID: b.OwnerID,
+				Weapon:  b.Weapon,
+				X:       b.originX,
+				Y:       b.originY,
+				VX:      b.vx,
+				VY:      b.vy,
+				Tick:    b.spawnTick,
+			})
+		}
+	}
+	snap.Pickups = g.pickupStates()
+	snap.Teams = append([]int(nil), g.teamPoints...)
+	sortSnapshot(&snap)
+	return snap
+}
+
+// ApplySnapshot replaces the world with the server's state
+func (g *Game) ApplySnapshot(msg SnapshotMessage) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.applySnapshot(msg)
+}
+
+// applySnapshot replaces the world with a snapshot, remembers it for
+// later deltas and acknowledges it, must hold mutex
+func (g *Game) applySnapshot(msg SnapshotMessage) {
+	if msg.Tick <= g.tick {
+		return // Out of date
+	}
+	g.tick = msg.Tick
+	g.Match = msg.Match
+	g.remember(msg)
+	defer g.sendAck(msg.Tick)
+
+	seen := make(map[string]bool, len(msg.Players))
+	for _, state := range msg.Players {
+		seen[state.ID] = true
+
+		// Kept so this peer can take over as host without losing acks
+		if g.lastInput == nil {
+			g.lastInput = make(map[string]uint32)
+		}
+		g.lastInput[state.ID] = state.Seq
+
+		player := g.applyPlayerState(MovementMessage{
+			Type:   "move",
+			ID:     state.ID,
+			X:      state.X,
+			Y:      state.Y,
+			Angle:  state.Angle,
+			Turret: state.Turret,
+			Speed:  state.Speed,
+			Weapon: state.Weapon,
+			Team:   state.Team,
+			Seq:    state.Seq,
+		})
+		player.Health = state.Health
+		player.Shield = state.Shield
+		player.Score = state.Score
+		if state.Eliminated && !player.eliminated && g.Match.respawning() {
+			player.respawn = RespawnDelay // Only counted down for the display
+		}
+		player.boost = state.Boost
+		player.rapid = state.Rapid
+		player.eliminated = state.Eliminated
+	}
+
+	if _, exists := g.Players[g.LocalPlayerID]; exists {
+		g.missingFromSnapshot = !seen[g.LocalPlayerID]
+	}
+
+	// Players the server no longer knows about have left
+	for id := range g.Players {
+		if !seen[id] && id != g.LocalPlayerID {
+			delete(g.Players, id)
+		}
+	}
+
+	g.applyPickupStates(msg.Pickups)
+	g.teamPoints = append(g.teamPoints[:0], msg.Teams...)
+
+	// Bullets are placed where they are at this tick
+	g.Bullets = g.Bullets[:0]
+	for _, b := range msg.Bullets {
+		steps := float64(msg.Tick - b.Tick)
+		g.Bullets = append(g.Bullets, Bullet{
+			ID:        b.ID,
+			X:         b.X + b.VX*steps,
+			Y:         b.Y + b.VY*steps,
+			vx:        b.VX,
+			vy:        b.VY,
+			Active:    true,
+			OwnerID:   b.OwnerID,
+			Weapon:    b.Weapon,
+			traveled:  math.Hypot(b.VX, b.VY) * steps,
+			originX:   b.X,
+			originY:   b.Y,
+			spawnTick: b.Tick,
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package game
+
+import (
+	"math"
+	"sort"
+)
+
+// Tank hull size, matching the sprite (330x244 drawn at 0.15 scale)
+const (
+	HullLength      = 48
+	HullWidth       = 36
+	CollisionPasses = 3 // Resolution passes per move, for tanks wedged between several others
+)
+
+// Wall is an axis-aligned obstacle tanks can't drive through
+type Wall struct {
+	X, Y, W, H float64
+}
+
+// ArenaWalls surround the screen so tanks stay inside it
+var ArenaWalls = []Wall{
+	{-100, -100, ScreenWidth + 200, 100},        // Top
+	{-100, ScreenHeight, ScreenWidth + 200, 100}, // Bottom
+	{-100, 0, 100, ScreenHeight},                // Left
+	{ScreenWidth, 0, 100, ScreenHeight},         // Right
+}
+
+// Box is an oriented rectangle given by its center, half size and rotation
+type Box struct {
+	X, Y         float64
+	HalfW, HalfH float64
+	Angle        float64
+}
+
+// HullBox returns a player's hull, rotated with the tank
+func HullBox(p *Player) Box {
+	return Box{X: p.X, Y: p.Y, HalfW: HullLength / 2, HalfH: HullWidth / 2, Angle: p.Angle}
+}
+
+// Box returns the wall as a (not rotated) box
+func (w Wall) Box() Box {
+	return Box{X: w.X + w.W/2, Y: w.Y + w.H/2, HalfW: w.W / 2, HalfH: w.H / 2}
+}
+
+// axes returns the box's two edge directions
+func (b Box) axes() [2][2]float64 {
+	sin, cos := math.Sincos(b.Angle)
+	return [2][2]float64{{cos, sin}, {-sin, cos}}
+}
+
+// project returns the box's center and half extent along an axis
+func (b Box) project(axis [2]float64) (float64, float64) {
+	edges := b.axes()
+	center := b.X*axis[0] + b.Y*axis[1]
+	radius := b.HalfW*math.Abs(edges[0][0]*axis[0]+edges[0][1]*axis[1]) +
+		b.HalfH*math.Abs(edges[1][0]*axis[0]+edges[1][1]*axis[1])
+	return center, radius
+}
+
+// Overlap tests two boxes with the separating axis theorem. When they
+// overlap it returns the shortest push that moves a out of b; boxes that
+// only touch do not overlap.
+func Overlap(a, b Box) (float64, float64, bool) {
+	// Cheap reject on the bounding circles first
+	reach := math.Hypot(a.HalfW, a.HalfH) + math.Hypot(b.HalfW, b.HalfH)
+	if math.Hypot(a.X-b.X, a.Y-b.Y) >= reach {
+		return 0, 0, false
+	}
+
+	aAxes, bAxes := a.axes(), b.axes()
+	best := math.Inf(1)
+	var pushX, pushY float64
+	for _, axis := range [4][2]float64{aAxes[0], aAxes[1], bAxes[0], bAxes[1]} {
+		centerA, radiusA := a.project(axis)
+		centerB, radiusB := b.project(axis)
+		depth := radiusA + radiusB - math.Abs(centerA-centerB)
+		if depth <= 0 {
+			return 0, 0, false // Found a gap
+		}
+		if depth < best {
+			best = depth
+			if centerA < centerB {
+				depth = -depth
+			}
+			pushX, pushY = axis[0]*depth, axis[1]*depth
+		}
+	}
+	return pushX, pushY, true
+}
+
+// collide pushes a tank that just moved out of other tanks and walls. Only
+// the tank that moved is pushed, the others stay where their owners put
+// them, so peers resolving their own tanks agree with an authority
+// resolving everyone's. Other tanks are looked up in the grid from the last
+// bullet step: they move far less than a cell between steps, so one more
+// cell of padding still finds every tank that can touch this one.
+func (g *Game) collide(player *Player) {
+	if g.grid.cells == nil {
+		g.grid.build(g.Players)
+	}
+
+	for pass := 0; pass < CollisionPasses; pass++ {
+		pushed := false
+		g.nearby = g.grid.near(player.X, player.Y, player.X, player.Y, 2*hullReach+GridCell, g.nearby[:0])
+		sort.Slice(g.nearby, func(i, j int) bool { return g.nearby[i].ID < g.nearby[j].ID }) // Same order on every peer
+		for _, other := range g.nearby {
+			if other == player || other.eliminated || g.Players[other.ID] != other {
+				continue // Ourselves, or gone since the grid was built
+			}
+			if dx, dy, ok := Overlap(HullBox(player), HullBox(other)); ok {
+				push(player, dx, dy)
+				pushed = true
+			}
+		}
+		// Walls last, they always win
+		for _, wall := range ArenaWalls {
+			if dx, dy, ok := Overlap(HullBox(player), wall.Box()); ok {
+				push(player, dx, dy)
+				pushed = true
+			}
+		}
+		if !pushed {
+			return
+		}
+	}
+}
+
+// push moves a player out of an obstacle, a tank driving into it stops
+func push(player *Player, dx, dy float64) {
+	player.X += dx
+	player.Y += dy
+
+	sin, cos := math.Sincos(player.Angle)
+	if (cos*dx+sin*dy)*player.Speed < 0 {
+		player.Speed = 0
+	}
+}
@@ -0,0 +1,99 @@
+package game_test
+
+import (
+	"math"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Box Overlap**
+func TestOverlapPushesShortestWay(t *testing.T) {
+	a := game.Box{X: 0, Y: 0, HalfW: 10, HalfH: 10}
+	b := game.Box{X: 15, Y: 2, HalfW: 10, HalfH: 10}
+
+	dx, dy, ok := game.Overlap(a, b)
+	if !ok {
+		t.Fatalf("Expected overlapping boxes to collide")
+	}
+	if math.Abs(dx+5) > 1e-9 || math.Abs(dy) > 1e-9 {
+		t.Errorf("Expected a push of (-5, 0), got (%f, %f)", dx, dy)
+	}
+}
+
+// ** Test Overlap Corner Cases**
+func TestOverlapCornerCases(t *testing.T) {
+	a := game.Box{X: 0, Y: 0, HalfW: 10, HalfH: 10}
+
+	// Edges touching exactly is not an overlap
+	if _, _, ok := game.Overlap(a, game.Box{X: 20, Y: 0, HalfW: 10, HalfH: 10}); ok {
+		t.Errorf("Expected touching boxes not to collide")
+	}
+
+	// Corner to corner: the bounding boxes overlap, the rotated box does not
+	diamond := game.Box{X: 22, Y: 22, HalfW: 10, HalfH: 10, Angle: math.Pi / 4}
+	if _, _, ok := game.Overlap(a, diamond); ok {
+		t.Errorf("Expected a rotated box past the corner not to collide")
+	}
+
+	// Same box pushed exactly back along the rotated axis
+	diamond.X, diamond.Y = 16, 16
+	dx, dy, ok := game.Overlap(a, diamond)
+	if !ok {
+		t.Fatalf("Expected a rotated box over the corner to collide")
+	}
+	if dx >= 0 || dy >= 0 {
+		t.Errorf("Expected the push to point away from the rotated box, got (%f, %f)", dx, dy)
+	}
+	a.X, a.Y = dx, dy
+	if _, _, ok := game.Overlap(a, diamond); ok {
+		t.Errorf("Expected the push to separate the boxes")
+	}
+}
+
+// ** Test Tank Collision**
+func TestTanksCannotDriveThroughEachOther(t *testing.T) {
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "player1",
+	}
+	gameInstance.Players["player1"] = &game.Player{ID: "player1", X: 100, Y: 100, Health: game.MaxHealth}
+	gameInstance.Players["player2"] = &game.Player{ID: "player2", X: 160, Y: 100, Health: game.MaxHealth}
+
+	for i := 0; i < 60; i++ {
+		gameInstance.ApplyLocalInput(game.PlayerInput{MoveX: 1})
+	}
+
+	mover, other := gameInstance.Players["player1"], gameInstance.Players["player2"]
+	if other.X != 160 || other.Y != 100 {
+		t.Errorf("Expected the other tank to stay put, got (%f, %f)", other.X, other.Y)
+	}
+	if _, _, ok := game.Overlap(game.HullBox(mover), game.HullBox(other)); ok {
+		t.Errorf("Expected the tanks to be separated, mover at (%f, %f)", mover.X, mover.Y)
+	}
+	if mover.X < 160-game.HullLength-0.001 {
+		t.Errorf("Expected the mover to stop against the other tank, got x %f", mover.X)
+	}
+}
+
+// ** Test Wall Collision**
+func TestTankStopsAtArenaWall(t *testing.T) {
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "player1",
+		Match:         game.MatchConfig{Movement: game.TankMovement},
+	}
+	gameInstance.Players["player1"] = &game.Player{ID: "player1", X: game.ScreenWidth - 60, Y: 300, Health: game.MaxHealth}
+
+	for i := 0; i < 120; i++ {
+		gameInstance.ApplyLocalInput(game.PlayerInput{MoveY: -1})
+	}
+
+	player := gameInstance.Players["player1"]
+	if math.Abs(player.X-(game.ScreenWidth-game.HullLength/2)) > 1e-6 {
+		t.Errorf("Expected the hull to rest against the wall, got x %f", player.X)
+	}
+	if player.Speed > game.TankAcceleration {
+		t.Errorf("Expected driving into the wall to stop the tank, got speed %f", player.Speed)
+	}
+}
@@ -0,0 +1,81 @@
+package game
+
+import "fmt"
+
+// Controller decides a player's input every frame instead of the keyboard,
+// see package bots
+type Controller interface {
+	NextInput(g *Game, self *Player) PlayerInput
+}
+
+// Eliminated reports whether the player is out of the match
+func (p *Player) Eliminated() bool {
+	return p.eliminated
+}
+
+// Velocity returns how far the bullet moves each frame
+func (b Bullet) Velocity() (float64, float64) {
+	return b.vx, b.vy
+}
+
+// AddBot spawns a computer-controlled player owned by this game. In the mesh
+// its moves, shots and hits are shared like the local player's; on an
+// authority (server or host) it plays like any connected client.
+func (g *Game) AddBot(id string, ctrl Controller) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.Bots == nil {
+		g.Bots = make(map[string]Controller)
+	}
+	x, y := g.getRandomSpawn(g.Players)
+	g.Players[id] = &Player{ID: id, X: x, Y: y, Health: MaxHealth, Team: g.pickTeam(0)}
+	g.Bots[id] = ctrl
+	fmt.Println("Bot joined:", id)
+}
+
+// ownsPlayer reports whether this game decides hits on a player
+func (g *Game) ownsPlayer(id string) bool {
+	return id == g.LocalPlayerID || g.Bots[id] != nil
+}
+
+// UpdateBots runs every bot owned by this game for one frame
+func (g *Game) UpdateBots() {
+	// A client of a server or host can only speak for its own player
+	if g.Client {
+		return
+	}
+
+	for id, ctrl := range g.Bots {
+		player, exists := g.Players[id]
+		if !exists {
+			delete(g.Bots, id) // Removed after elimination
+			delete(g.botSeq, id)
+			continue
+		}
+		if player.eliminated {
+			continue
+		}
+
+		input := ctrl.NextInput(g, player)
+
+		// An authority takes a bot's input like a connected client's, with
+		// sequence numbers and the same rate limit, and shares it through
+		// snapshots
+		if g.Authoritative {
+			if g.botSeq == nil {
+				g.botSeq = make(map[string]uint32)
+			}
+			g.botSeq[id]++
+			input.Seq = g.botSeq[id]
+			g.ApplyRemoteInput(InputMessage{Type: "input", ID: id, PlayerInput: input})
+			continue
+		}
+
+		// In the mesh it moves like the local player
+		input.MoveX = clamp(input.MoveX, -1, 1)
+		input.MoveY = clamp(input.MoveY, -1, 1)
+		coolDown(player)
+		g.applyPeerInput(player, input)
+	}
+}
@@ -0,0 +1,304 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Action is something the local player can do, bound to one or more inputs
+type Action string
+
+const (
+	MoveUp    Action = "up"
+	MoveDown  Action = "down"
+	MoveLeft  Action = "left"
+	MoveRight Action = "right"
+	Fire      Action = "fire"
+	Switch    Action = "weapon" // Cycle to the next weapon
+)
+
+// Actions lists every bindable action in menu order
+var Actions = []Action{MoveUp, MoveDown, MoveLeft, MoveRight, Fire, Switch}
+
+// Input devices a binding can come from
+const (
+	DeviceKey   = "key"
+	DeviceMouse = "mouse"
+	DevicePad   = "pad"
+)
+
+var mouseButtons = map[string]ebiten.MouseButton{
+	"left":   ebiten.MouseButtonLeft,
+	"right":  ebiten.MouseButtonRight,
+	"middle": ebiten.MouseButtonMiddle,
+}
+
+// padButtons names the standard gamepad layout buttons (Xbox style)
+var padButtons = map[string]ebiten.StandardGamepadButton{
+	"A":          ebiten.StandardGamepadButtonRightBottom,
+	"B":          ebiten.StandardGamepadButtonRightRight,
+	"X":          ebiten.StandardGamepadButtonRightLeft,
+	"Y":          ebiten.StandardGamepadButtonRightTop,
+	"LB":         ebiten.StandardGamepadButtonFrontTopLeft,
+	"RB":         ebiten.StandardGamepadButtonFrontTopRight,
+	"LT":         ebiten.StandardGamepadButtonFrontBottomLeft,
+	"RT":         ebiten.StandardGamepadButtonFrontBottomRight,
+	"Back":       ebiten.StandardGamepadButtonCenterLeft,
+	"Start":      ebiten.StandardGamepadButtonCenterRight,
+	"LeftStick":  ebiten.StandardGamepadButtonLeftStick,
+	"RightStick": ebiten.StandardGamepadButtonRightStick,
+	"DpadUp":     ebiten.StandardGamepadButtonLeftTop,
+	"DpadDown":   ebiten.StandardGamepadButtonLeftBottom,
+	"DpadLeft":   ebiten.StandardGamepadButtonLeftLeft,
+	"DpadRight":  ebiten.StandardGamepadButtonLeftRight,
+	"Home":       ebiten.StandardGamepadButtonCenterCenter,
+}
+
+// Binding is one physical input: a keyboard key, mouse button or gamepad button
+type Binding struct {
+	Device string `json:"device"` // "key", "mouse" or "pad"
+	Name   string `json:"name"`   // Ebiten key name (e.g. "W"), "left"/"right"/"middle" or a pad button (e.g. "RT")
+}
+
+func (b Binding) String() string {
+	return b.Device + " " + b.Name
+}
+
+// validate reports whether the binding names a real input
+func (b Binding) validate() error {
+	var ok bool
+	switch b.Device {
+	case DeviceKey:
+		var key ebiten.Key
+		ok = key.UnmarshalText([]byte(b.Name)) == nil
+	case DeviceMouse:
+		_, ok = mouseButtons[b.Name]
+	case DevicePad:
+		_, ok = padButtons[b.Name]
+	}
+	if !ok {
+		return fmt.Errorf("unknown binding %q", b.String())
+	}
+	return nil
+}
+
+// Controls maps actions to inputs and configures the gamepad sticks. The
+// move stick drives the tank, the aim stick turns the turret; without a
+// deflected aim stick the turret follows the mouse cursor.
+type Controls struct {
+	Bindings   map[Action][]Binding `json:"bindings"`
+	Deadzone   float64              `json:"deadzone"`    // Stick deflection ignored as drift (0..1)
+	Trigger    float64              `json:"trigger"`     // How far an analog trigger must be pulled to count (0..1)
+	SwapSticks bool                 `json:"swap_sticks"` // Move with the right stick and aim with the left
+
+	cursorX, cursorY int // Cursor position last frame, to notice the mouse taking over aim
+}
+
+// DefaultControls returns WASD/arrows to move, Space or the left mouse
+// button to fire, and the usual twin-stick gamepad layout
+func DefaultControls() *Controls {
+	return &Controls{
+		Bindings: map[Action][]Binding{
+			MoveUp:    {{DeviceKey, "W"}, {DeviceKey, "ArrowUp"}, {DevicePad, "DpadUp"}},
+			MoveDown:  {{DeviceKey, "S"}, {DeviceKey, "ArrowDown"}, {DevicePad, "DpadDown"}},
+			MoveLeft:  {{DeviceKey, "A"}, {DeviceKey, "ArrowLeft"}, {DevicePad, "DpadLeft"}},
+			MoveRight: {{DeviceKey, "D"}, {DeviceKey, "ArrowRight"}, {DevicePad, "DpadRight"}},
+			Fire:      {{DeviceKey, "Space"}, {DeviceMouse, "left"}, {DevicePad, "RT"}},
+			Switch:    {{DeviceKey, "Q"}, {DeviceMouse, "right"}, {DevicePad, "Y"}},
+		},
+		Deadzone: 0.2,
+		Trigger:  0.5,
+	}
+}
+
+// LoadControls reads controls from a JSON file. A missing file gives the
+// defaults, and actions the file leaves out keep their default bindings.
+func LoadControls(path string) (*Controls, error) {
+	controls := DefaultControls()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return controls, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := DefaultControls()
+	loaded.Bindings = nil
+	if err := json.Unmarshal(data, loaded); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for action, bindings := range loaded.Bindings {
+		for _, b := range bindings {
+			if err := b.validate(); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, action, err)
+			}
+		}
+		controls.Bindings[action] = bindings
+	}
+	controls.Deadzone = clamp(loaded.Deadzone, 0, 0.95)
+	controls.Trigger = clamp(loaded.Trigger, 0.05, 1)
+	controls.SwapSticks = loaded.SwapSticks
+	return controls, nil
+}
+
+// Save writes the controls to a JSON file
+func (c *Controls) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Bind adds an input to an action, taking it away from any other action
+// so one button never does two things
+func (c *Controls) Bind(action Action, b Binding) error {
+	if err := b.validate(); err != nil {
+		return err
+	}
+	for other, bindings := range c.Bindings {
+		kept := bindings[:0]
+		for _, existing := range bindings {
+			if existing != b {
+				kept = append(kept, existing)
+			}
+		}
+		c.Bindings[other] = kept
+	}
+	c.Bindings[action] = append(c.Bindings[action], b)
+	return nil
+}
+
+// Clear removes every input bound to an action
+func (c *Controls) Clear(action Action) {
+	c.Bindings[action] = nil
+}
+
+// pressed reports whether any input bound to the action is held
+func (c *Controls) pressed(action Action, pads []ebiten.GamepadID) bool {
+	for _, b := range c.Bindings[action] {
+		switch b.Device {
+		case DeviceKey:
+			var key ebiten.Key
+			if key.UnmarshalText([]byte(b.Name)) == nil && ebiten.IsKeyPressed(key) {
+				return true
+			}
+		case DeviceMouse:
+			if button, ok := mouseButtons[b.Name]; ok && ebiten.IsMouseButtonPressed(button) {
+				return true
+			}
+		case DevicePad:
+			button, ok := padButtons[b.Name]
+			if !ok {
+				continue
+			}
+			// Analog triggers report how far they are pulled, buttons 0 or 1
+			for _, id := range pads {
+				if ebiten.StandardGamepadButtonValue(id, button) >= c.Trigger {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// justPressed reports whether an input bound to the action went down this frame
+func (c *Controls) justPressed(action Action, pads []ebiten.GamepadID) bool {
+	for _, b := range c.Bindings[action] {
+		switch b.Device {
+		case DeviceKey:
+			var key ebiten.Key
+			if key.UnmarshalText([]byte(b.Name)) == nil && inpututil.IsKeyJustPressed(key) {
+				return true
+			}
+		case DeviceMouse:
+			if button, ok := mouseButtons[b.Name]; ok && inpututil.IsMouseButtonJustPressed(button) {
+				return true
+			}
+		case DevicePad:
+			button, ok := padButtons[b.Name]
+			if !ok {
+				continue
+			}
+			for _, id := range pads {
+				if inpututil.IsStandardGamepadButtonJustPressed(id, button) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Stick reads a stick, dropping deflection inside the deadzone and
+// rescaling the rest so movement still starts from zero
+func (c *Controls) Stick(x, y float64) (float64, float64) {
+	length := math.Hypot(x, y)
+	if length <= c.Deadzone {
+		return 0, 0
+	}
+	scale := math.Min(1, (length-c.Deadzone)/(1-c.Deadzone)) / length
+	return x * scale, y * scale
+}
+
+// Read samples keyboard, mouse and gamepads into one frame of input for
+// the local player
+func (c *Controls) Read(player *Player) PlayerInput {
+	var pads []ebiten.GamepadID
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			pads = append(pads, id)
+		}
+	}
+
+	input := PlayerInput{Aim: player.TurretAngle, Fire: c.pressed(Fire, pads)}
+	if c.pressed(MoveUp, pads) {
+		input.MoveY--
+	}
+	if c.pressed(MoveDown, pads) {
+		input.MoveY++
+	}
+	if c.pressed(MoveLeft, pads) {
+		input.MoveX--
+	}
+	if c.pressed(MoveRight, pads) {
+		input.MoveX++
+	}
+	if c.justPressed(Switch, pads) {
+		input.Weapon = NextWeapon(player.Weapon)
+	}
+
+	moveX, moveY := ebiten.StandardGamepadAxisLeftStickHorizontal, ebiten.StandardGamepadAxisLeftStickVertical
+	aimX, aimY := ebiten.StandardGamepadAxisRightStickHorizontal, ebiten.StandardGamepadAxisRightStickVertical
+	if c.SwapSticks {
+		moveX, moveY, aimX, aimY = aimX, aimY, moveX, moveY
+	}
+	aimed := false
+	for _, id := range pads {
+		if x, y := c.Stick(ebiten.StandardGamepadAxisValue(id, moveX), ebiten.StandardGamepadAxisValue(id, moveY)); x != 0 || y != 0 {
+			input.MoveX, input.MoveY = x, y
+		}
+		if x, y := c.Stick(ebiten.StandardGamepadAxisValue(id, aimX), ebiten.StandardGamepadAxisValue(id, aimY)); x != 0 || y != 0 {
+			input.Aim = math.Atan2(y, x)
+			aimed = true
+		}
+	}
+
+	// The mouse aims unless a stick is; with a gamepad plugged in the
+	// turret stays put until the mouse actually moves
+	cursorX, cursorY := ebiten.CursorPosition()
+	moved := cursorX != c.cursorX || cursorY != c.cursorY
+	c.cursorX, c.cursorY = cursorX, cursorY
+	if !aimed && (len(pads) == 0 || moved) {
+		input.Aim = math.Atan2(float64(cursorY)-player.Y, float64(cursorX)-player.X)
+	}
+	return input
+}
@@ -0,0 +1,79 @@
+package game_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Controls Round Trip**
+func TestControlsSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "controls.json")
+
+	// No file yet: defaults
+	controls, err := game.LoadControls(path)
+	if err != nil {
+		t.Fatalf("Failed to load missing controls: %v", err)
+	}
+	if len(controls.Bindings[game.Fire]) == 0 {
+		t.Fatalf("Expected default fire bindings")
+	}
+
+	// Rebinding Space to up takes it away from fire
+	space := game.Binding{Device: game.DeviceKey, Name: "Space"}
+	if err := controls.Bind(game.MoveUp, space); err != nil {
+		t.Fatalf("Failed to bind: %v", err)
+	}
+	controls.SwapSticks = true
+	if err := controls.Save(path); err != nil {
+		t.Fatalf("Failed to save controls: %v", err)
+	}
+
+	loaded, err := game.LoadControls(path)
+	if err != nil {
+		t.Fatalf("Failed to load controls: %v", err)
+	}
+	if !loaded.SwapSticks {
+		t.Errorf("Expected stick settings to be saved")
+	}
+	for _, b := range loaded.Bindings[game.Fire] {
+		if b == space {
+			t.Errorf("Expected Space to be unbound from fire")
+		}
+	}
+	up := loaded.Bindings[game.MoveUp]
+	if len(up) == 0 || up[len(up)-1] != space {
+		t.Errorf("Expected Space to be bound to up, got %v", up)
+	}
+}
+
+// ** Test Invalid Bindings**
+func TestControlsRejectUnknownInputs(t *testing.T) {
+	controls := game.DefaultControls()
+	if err := controls.Bind(game.Fire, game.Binding{Device: game.DevicePad, Name: "Turbo"}); err == nil {
+		t.Errorf("Expected an unknown gamepad button to be rejected")
+	}
+
+	path := filepath.Join(t.TempDir(), "controls.json")
+	os.WriteFile(path, []byte(`{"bindings": {"fire": [{"device": "key", "name": "NoSuchKey"}]}}`), 0644)
+	if _, err := game.LoadControls(path); err == nil {
+		t.Errorf("Expected a file with an unknown key to be rejected")
+	}
+}
+
+// ** Test Stick Deadzone**
+func TestStickDeadzone(t *testing.T) {
+	controls := game.DefaultControls()
+
+	if x, y := controls.Stick(0.1, -0.1); x != 0 || y != 0 {
+		t.Errorf("Expected drift inside the deadzone to be ignored, got (%f, %f)", x, y)
+	}
+	if x, _ := controls.Stick(1, 0); x != 1 {
+		t.Errorf("Expected a full push to stay full, got %f", x)
+	}
+	if x, _ := controls.Stick(0.6, 0); x <= 0 || x >= 0.6 {
+		t.Errorf("Expected a partial push to be rescaled past the deadzone, got %f", x)
+	}
+}
@@ -0,0 +1,171 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Hull armor: direct hits deal the weapon's damage times the multiplier of
+// the side they land on. Splash damage wraps around the hull and ignores it.
+const (
+	FrontArmor = 1.0 // Within 45 degrees of where the hull faces
+	SideArmor  = 1.5
+	RearArmor  = 2.0 // Within 45 degrees of straight behind
+)
+
+// Sides of the hull a hit can land on
+const (
+	HitFront  = "front"
+	HitSide   = "side"
+	HitRear   = "rear"
+	HitSplash = "splash"
+)
+
+// Regenerating shield settings (MatchConfig.ShieldRegen), in frames at 60 FPS
+const (
+	RegenShield   = 25  // Shield a tank regenerates up to, pickups can go above it
+	RegenDelay    = 180 // Frames without damage before the shield starts to recharge
+	RegenInterval = 6   // Frames per shield point recharged
+)
+
+// HitIndicatorFrames is how long the local player sees where a hit came from
+const HitIndicatorFrames = 60
+
+// DamageReport is the breakdown of one hit, carried by HitMessage
+type DamageReport struct {
+	Side     string  `json:"side,omitempty"`     // HitFront, HitSide, HitRear or HitSplash
+	From     float64 `json:"from"`               // Direction from the victim towards where the hit came from
+	Base     int     `json:"base,omitempty"`     // The weapon's damage before armor
+	Damage   int     `json:"damage,omitempty"`   // Damage after armor
+	Absorbed int     `json:"absorbed,omitempty"` // Part of Damage the shield took
+}
+
+// hitIndicator is a recent hit on the local player, shown around its tank
+type hitIndicator struct {
+	DamageReport
+	frames int // Frames left on screen
+}
+
+// armorFor returns the damage multiplier of a hull side
+func armorFor(side string) float64 {
+	switch side {
+	case HitRear:
+		return RearArmor
+	case HitSide:
+		return SideArmor
+	}
+	return FrontArmor
+}
+
+// hitSide returns which side of a hull faces direction from
+func hitSide(p *Player, from float64) string {
+	rel := math.Abs(math.Remainder(from-p.Angle, 2*math.Pi))
+	switch {
+	case rel <= math.Pi/4:
+		return HitFront
+	case rel >= 3*math.Pi/4:
+		return HitRear
+	}
+	return HitSide
+}
+
+// impactDirection returns the direction from a player towards where a
+// projectile came from: against its flight, or towards it if it stands still
+func impactDirection(p *Player, b Bullet) float64 {
+	if b.vx != 0 || b.vy != 0 {
+		return math.Atan2(-b.vy, -b.vx)
+	}
+	return math.Atan2(b.Y-p.Y, b.X-p.X)
+}
+
+// applyDamage hurts a player, shield first, and reports how
+func applyDamage(victim *Player, b Bullet, base int, direct bool) DamageReport {
+	report := DamageReport{Side: HitSplash, Base: base, Damage: base}
+	if direct {
+		report.From = impactDirection(victim, b)
+		report.Side = hitSide(victim, report.From)
+		report.Damage = int(math.Round(float64(base) * armorFor(report.Side)))
+	} else {
+		report.From = math.Atan2(b.Y-victim.Y, b.X-victim.X)
+	}
+
+	report.Absorbed = report.Damage
+	if report.Absorbed > victim.Shield {
+		report.Absorbed = victim.Shield
+	}
+	victim.Shield -= report.Absorbed
+	victim.Health -= report.Damage - report.Absorbed
+	if victim.Health < 0 {
+		victim.Health = 0
+	}
+	victim.sinceHit = 0
+	return report
+}
+
+// updateShields recharges regenerating shields, must hold mutex. Every peer
+// recharges every tank, hit messages carry the owner's value when it matters.
+func (g *Game) updateShields() {
+	if !g.Match.ShieldRegen || g.Client {
+		return
+	}
+	for _, p := range g.Players {
+		if p.eliminated {
+			continue
+		}
+		p.sinceHit++
+		if p.sinceHit >= RegenDelay && p.Shield < RegenShield && (p.sinceHit-RegenDelay)%RegenInterval == 0 {
+			p.Shield++
+		}
+	}
+}
+
+// indicateHit shows the local player where a hit came from
+func (g *Game) indicateHit(victimID string, report DamageReport) {
+	if victimID != g.LocalPlayerID || report.Damage == 0 {
+		return
+	}
+	g.indicators = append(g.indicators, hitIndicator{DamageReport: report, frames: HitIndicatorFrames})
+}
+
+// updateIndicators fades hit indicators, must hold mutex
+func (g *Game) updateIndicators() {
+	kept := g.indicators[:0]
+	for _, ind := range g.indicators {
+		if ind.frames--; ind.frames > 0 {
+			kept = append(kept, ind)
+		}
+	}
+	g.indicators = kept
+}
+
+// drawIndicators renders a fading arc around the local tank towards each
+// recent hit, labeled with the damage and the side it landed on
+func (g *Game) drawIndicators(screen *ebiten.Image) {
+	player, exists := g.Players[g.LocalPlayerID]
+	if !exists {
+		return
+	}
+	x, y := g.renderPosition(player)
+	radius := float32(HullLength/2 + 16)
+	for _, ind := range g.indicators {
+		alpha := uint8(255 * ind.frames / HitIndicatorFrames)
+		dim := uint8(int(alpha) * 40 / 255)
+		c := color.RGBA{alpha, dim, dim, alpha} // Premultiplied red
+
+		for step := -4; step <= 4; step++ {
+			angle := ind.From + float64(step)*0.1
+			dx, dy := float32(math.Cos(angle)), float32(math.Sin(angle))
+			vector.DrawFilledCircle(screen, float32(x)+dx*radius, float32(y)+dy*radius, 3, c, true)
+		}
+
+		label := fmt.Sprintf("-%d %s", ind.Damage, ind.Side)
+		lx := x + math.Cos(ind.From)*float64(radius+14)
+		ly := y + math.Sin(ind.From)*float64(radius+14)
+		ebitenutil.DebugPrintAt(screen, label, int(lx)-len(label)*3, int(ly)-8)
+	}
+}
@@ -0,0 +1,105 @@
+package game_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Directional Damage**
+func TestRearAndSideHitsDealMore(t *testing.T) {
+	cases := []struct {
+		angle  float64 // Victim's hull, the bullet flies towards +X
+		side   string
+		damage int
+	}{
+		{math.Pi, game.HitFront, game.DamageAmount},
+		{math.Pi / 2, game.HitSide, int(math.Round(game.DamageAmount * game.SideArmor))},
+		{0, game.HitRear, int(math.Round(game.DamageAmount * game.RearArmor))},
+	}
+	for _, c := range cases {
+		var hit game.HitMessage
+		gameInstance := &game.Game{
+			Players:       make(map[string]*game.Player),
+			LocalPlayerID: "victim",
+			SendUpdate: func(msg interface{}) {
+				if h, ok := msg.(game.HitMessage); ok {
+					hit = h
+				}
+			},
+		}
+		gameInstance.Players["victim"] = &game.Player{ID: "victim", X: 50, Y: 50, Angle: c.angle, Health: 100, Shield: 2}
+		gameInstance.AddBulletFromPeer(game.BulletMessage{ID: 1, OwnerID: "shooter", X: 50, Y: 55, VX: 4})
+
+		gameInstance.UpdateBullets()
+
+		if hit.Side != c.side || hit.Damage != c.damage || hit.Base != game.DamageAmount {
+			t.Errorf("Hull at %.2f: expected a %s hit for %d, got %+v", c.angle, c.side, c.damage, hit.DamageReport)
+		}
+		if hit.Absorbed != 2 || hit.Health != 100-c.damage+2 {
+			t.Errorf("Hull at %.2f: expected the shield to absorb 2, got %d absorbed and health %d", c.angle, hit.Absorbed, hit.Health)
+		}
+		if math.Abs(math.Abs(hit.From)-math.Pi) > 1e-9 {
+			t.Errorf("Hull at %.2f: expected the hit to come from the left, got %f", c.angle, hit.From)
+		}
+	}
+}
+
+// ** Test Shield Regeneration**
+func TestShieldRegeneratesOutOfCombat(t *testing.T) {
+	server := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+		Match:         game.MatchConfig{ShieldRegen: true},
+	}
+	server.Players["player1"] = &game.Player{ID: "player1", X: 300, Y: 300, Health: game.MaxHealth}
+
+	for i := 0; i < game.RegenDelay-1; i++ {
+		server.Tick()
+	}
+	if shield := server.Players["player1"].Shield; shield != 0 {
+		t.Fatalf("Expected no shield before the regeneration delay, got %d", shield)
+	}
+
+	// A hit restarts the delay
+	server.Bullets = append(server.Bullets, game.Bullet{ID: 1, X: 305, Y: 305, Active: true, OwnerID: "player2"})
+	for i := 0; i < game.RegenDelay-1; i++ {
+		server.Tick()
+	}
+	if shield := server.Players["player1"].Shield; shield != 0 {
+		t.Fatalf("Expected the hit to delay the shield, got %d", shield)
+	}
+
+	for i := 0; i < game.RegenInterval*game.RegenShield*2; i++ {
+		server.Tick()
+	}
+	if shield := server.Players["player1"].Shield; shield != game.RegenShield {
+		t.Errorf("Expected the shield to recharge to %d and stop, got %d", game.RegenShield, shield)
+	}
+}
+
+// ** Test Hit Report Reaches The Victim**
+func TestHitReportSurvivesTheWire(t *testing.T) {
+	data, _ := json.Marshal(game.HitMessage{
+		Type:         "hit",
+		VictimID:     "player1",
+		ShooterID:    "player2",
+		Health:       90,
+		DamageReport: game.DamageReport{Side: game.HitRear, From: 1.5, Base: 5, Damage: 10},
+	})
+
+	client := &game.Game{Players: make(map[string]*game.Player), LocalPlayerID: "player1", Client: true}
+	client.Players["player1"] = &game.Player{ID: "player1", X: 300, Y: 300, Health: game.MaxHealth}
+	client.HandleMessage(data)
+
+	var decoded game.HitMessage
+	json.Unmarshal(data, &decoded)
+	if decoded.Side != game.HitRear || decoded.From != 1.5 || decoded.Damage != 10 {
+		t.Errorf("Expected the damage breakdown in the hit message, got %+v", decoded.DamageReport)
+	}
+	if client.Players["player1"].Health != 90 {
+		t.Errorf("Expected the client to take the reported health, got %d", client.Players["player1"].Health)
+	}
+}
@@ -0,0 +1,187 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// RespawnDelay is how many frames an eliminated tank waits in the
+// respawning modes (deathmatch and king of the hill)
+const RespawnDelay = 180
+
+// RespawnMessage struct (sent by a mesh peer when one of its tanks respawns)
+type RespawnMessage struct {
+	Type string  `json:"type"` // "respawn"
+	ID   string  `json:"id"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+}
+
+// elapsed returns the frames played in this match. An authority and its
+// clients count snapshot ticks, a mesh peer its own frames or those of the
+// longest-running peer it has heard from.
+func (g *Game) elapsed() int {
+	if g.Authoritative || g.Client {
+		return int(g.tick)
+	}
+	return g.matchFrame
+}
+
+// matchOver reports whether a respawning mode hit its score or time
+// limit, must hold mutex
+func (g *Game) matchOver() bool {
+	if !g.Match.respawning() {
+		return false
+	}
+	if g.Match.TimeLimit > 0 && g.elapsed() >= g.Match.TimeLimit*60 {
+		return true
+	}
+	if g.Match.ScoreLimit == 0 {
+		return false
+	}
+	for team := 1; team <= g.Match.Teams; team++ {
+		if g.teamScore(team) >= g.Match.ScoreLimit {
+			return true
+		}
+	}
+	for _, p := range g.Players {
+		if g.Match.Teams == 0 && p.Score >= g.Match.ScoreLimit {
+			return true
+		}
+	}
+	return false
+}
+
+// standings returns the players by score, best first, must hold mutex
+func (g *Game) standings() []*Player {
+	players := make([]*Player, 0, len(g.Players))
+	for _, p := range g.Players {
+		players = append(players, p)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].Score != players[j].Score {
+			return players[i].Score > players[j].Score
+		}
+		return players[i].ID < players[j].ID
+	})
+	return players
+}
+
+// updateMatch advances the match clock and the hill, counts down
+// eliminated tanks and brings back the ones this game decides for, must
+// hold mutex
+func (g *Game) updateMatch() {
+	if !g.Match.respawning() {
+		return
+	}
+	if !g.Authoritative && !g.Client {
+		g.matchFrame++
+	}
+	g.updateHill()
+
+	for id, p := range g.Players {
+		if !p.eliminated || p.respawn <= 0 {
+			continue
+		}
+		p.respawn--
+		// Clients only count down for the display, the authority respawns them
+		if p.respawn == 0 && (g.Authoritative || (g.ownsPlayer(id) && !g.Client)) {
+			g.respawnPlayer(p)
+		}
+	}
+}
+
+// respawnPlayer brings a tank back at a spawn point away from the others
+func (g *Game) respawnPlayer(p *Player) {
+	others := make(map[string]*Player, len(g.Players))
+	for id, other := range g.Players {
+		if id != p.ID && !other.eliminated {
+			others[id] = other
+		}
+	}
+	x, y := g.getRandomSpawn(others)
+	g.placeRespawn(p, x, y)
+	fmt.Println("Player", p.ID, "respawned")
+
+	// An authority shares it through snapshots
+	if !g.Authoritative {
+		msg := RespawnMessage{Type: "respawn", ID: p.ID, X: x, Y: y}
+		g.Recorder.Record(RecordOut, msg)
+		if g.SendUpdate != nil {
+			g.SendUpdate(msg)
+		}
+	}
+}
+
+// placeRespawn resets a tank to a fresh one at x, y
+func (g *Game) placeRespawn(p *Player, x, y float64) {
+	p.X, p.Y = x, y
+	p.Health = MaxHealth
+	p.Shield = 0
+	p.Speed = 0
+	p.Weapon = ""
+	p.cooldown, p.shots, p.reloading, p.boost, p.rapid = 0, 0, 0, 0, 0
+	p.eliminated = false
+	p.respawn = 0
+	p.sinceHit = 0
+}
+
+// ApplyRespawn brings back a tank another mesh peer respawned
+func (g *Game) ApplyRespawn(msg RespawnMessage) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	p, exists := g.Players[msg.ID]
+	if !exists {
+		p = &Player{ID: msg.ID}
+		g.Players[msg.ID] = p
+	}
+	g.placeRespawn(p, msg.X, msg.Y)
+}
+
+// drawScoreboard shows deathmatch scores, the clock, the respawn
+// countdown and the result once the match is over
+func (g *Game) drawScoreboard(screen *ebiten.Image) {
+	if !g.Match.respawning() {
+		return
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	header := "Deathmatch"
+	if g.Match.Mode == KingOfTheHillMode {
+		header = "King of the hill"
+	}
+	if g.Match.ScoreLimit > 0 {
+		header += fmt.Sprintf("  first to %d", g.Match.ScoreLimit)
+	}
+	if g.Match.TimeLimit > 0 {
+		left := g.Match.TimeLimit - g.elapsed()/60
+		if left < 0 {
+			left = 0
+		}
+		header += fmt.Sprintf("  %d:%02d left", left/60, left%60)
+	}
+	lines := header + g.teamLines()
+	for _, p := range g.standings() {
+		if g.Match.Teams > 0 {
+			lines += fmt.Sprintf("\n%3d  %s (%s)", p.Score, p.ID, teamName(p.Team))
+		} else {
+			lines += fmt.Sprintf("\n%3d  %s", p.Score, p.ID)
+		}
+	}
+	ebitenutil.DebugPrintAt(screen, lines, ScreenWidth-260, 10)
+
+	if g.matchOver() {
+		ebitenutil.DrawRect(screen, 0, ScreenHeight/2-30, ScreenWidth, 60, color.RGBA{0, 0, 0, 180})
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("MATCH OVER - %s wins", g.leader()), ScreenWidth/2-120, ScreenHeight/2-8)
+		return
+	}
+	if player, exists := g.Players[g.LocalPlayerID]; exists && player.eliminated {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Respawning in %d...", player.respawn/60+1), ScreenWidth/2-60, ScreenHeight/2-8)
+	}
+}
@@ -0,0 +1,119 @@
+package game_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Deathmatch Respawn**
+func TestDeathmatchScoresAndRespawns(t *testing.T) {
+	server := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+		Match:         game.MatchConfig{Mode: game.DeathmatchMode, ScoreLimit: 2},
+	}
+	server.Players["shooter"] = &game.Player{ID: "shooter", X: 400, Y: 400, Health: game.MaxHealth}
+	server.Players["victim"] = &game.Player{ID: "victim", X: 50, Y: 50, Health: game.DamageAmount}
+	server.Bullets = append(server.Bullets, game.Bullet{ID: 1, X: 55, Y: 55, Active: true, OwnerID: "shooter"})
+
+	server.Tick()
+	victim := server.Players["victim"]
+	if !victim.Eliminated() || server.Players["shooter"].Score != 1 {
+		t.Fatalf("Expected the victim eliminated and the shooter scored, got eliminated %v score %d",
+			victim.Eliminated(), server.Players["shooter"].Score)
+	}
+
+	for i := 0; i < game.RespawnDelay; i++ {
+		server.Tick()
+	}
+	if victim.Eliminated() || victim.Health != game.MaxHealth {
+		t.Errorf("Expected the victim to respawn with full health, got eliminated %v health %d", victim.Eliminated(), victim.Health)
+	}
+	if server.Players["victim"] != victim {
+		t.Errorf("Expected the victim to stay in the match")
+	}
+
+	// Reaching the score limit ends the match, bullets stop flying
+	victim.Health = game.DamageAmount
+	server.Bullets = append(server.Bullets[:0], game.Bullet{ID: 2, X: victim.X + 5, Y: victim.Y + 5, Active: true, OwnerID: "shooter"})
+	server.Tick()
+	server.Bullets = append(server.Bullets[:0], game.Bullet{ID: 3, X: 100, Y: 100, Active: true, OwnerID: "shooter"})
+	server.Tick()
+	if server.Players["shooter"].Score != 2 || server.Bullets[0].X != 100 {
+		t.Errorf("Expected the match to stop at the score limit, score %d bullet x %f", server.Players["shooter"].Score, server.Bullets[0].X)
+	}
+}
+
+// ** Test Mesh Respawn**
+func TestMeshPeerAnnouncesRespawn(t *testing.T) {
+	var sent [][]byte
+	owner := &game.Game{
+		Players:         make(map[string]*game.Player),
+		LocalPlayerID:   "peer-a",
+		LocalController: idle{},
+		Match:           game.MatchConfig{Mode: game.DeathmatchMode},
+		SendUpdate: func(msg interface{}) {
+			data, _ := json.Marshal(msg)
+			sent = append(sent, data)
+		},
+	}
+	watcher := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "peer-b",
+		Match:         game.MatchConfig{Mode: game.DeathmatchMode},
+	}
+	owner.Players["peer-a"] = &game.Player{ID: "peer-a", X: 100, Y: 100, Health: game.DamageAmount}
+	owner.Players["peer-b"] = &game.Player{ID: "peer-b", X: 300, Y: 300, Health: game.MaxHealth}
+	watcher.Players["peer-a"] = &game.Player{ID: "peer-a", X: 100, Y: 100, Health: game.DamageAmount}
+
+	// Our tank is hit, the other peer hears about it
+	owner.AddBulletFromPeer(game.BulletMessage{ID: 1, OwnerID: "peer-b", X: 101, Y: 105, VX: 4})
+	owner.Update()
+	for _, data := range sent {
+		watcher.HandleMessage(data)
+	}
+	sent = nil
+	if !watcher.Players["peer-a"].Eliminated() {
+		t.Fatalf("Expected the elimination to reach the other peer")
+	}
+
+	for i := 0; i < game.RespawnDelay; i++ {
+		owner.Update()
+	}
+	for _, data := range sent {
+		watcher.HandleMessage(data)
+	}
+	if p := watcher.Players["peer-a"]; p.Eliminated() || p.Health != game.MaxHealth {
+		t.Errorf("Expected the respawn to reach the other peer, got eliminated %v health %d", p.Eliminated(), p.Health)
+	}
+}
+
+type mover struct{}
+
+func (mover) NextInput(g *game.Game, self *game.Player) game.PlayerInput {
+	return game.PlayerInput{MoveX: 1}
+}
+
+// ** Test Mesh Match Clock And Scores**
+func TestLateMeshPeerSharesClockAndScores(t *testing.T) {
+	late := &game.Game{
+		Players:         make(map[string]*game.Player),
+		LocalPlayerID:   "peer-b",
+		LocalController: mover{},
+		Match:           game.MatchConfig{Mode: game.DeathmatchMode, TimeLimit: 1},
+	}
+	late.Players["peer-b"] = &game.Player{ID: "peer-b", X: 100, Y: 100, Health: game.MaxHealth}
+
+	// peer-a has played almost the whole minute and eliminated three tanks
+	late.HandleMessage([]byte(`{"type":"move","id":"peer-a","x":300,"y":300,"score":3,"frame":59}`))
+	if score := late.Players["peer-a"].Score; score != 3 {
+		t.Errorf("Expected peer-a's score from its owner, got %d", score)
+	}
+
+	late.Update()
+	if x := late.Players["peer-b"].X; x != 100 {
+		t.Errorf("Expected the match to end with peer-a's clock, still moving at x %f", x)
+	}
+}
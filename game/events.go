@@ -0,0 +1,87 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+)
+
+// RemoveDelay is how many frames an eliminated or disconnected tank stays
+// on screen before it is removed (~3s)
+const RemoveDelay = 180
+
+// event is something the network delivered for the game goroutine: a
+// message, or a peer that disconnected
+type event struct {
+	data []byte
+	left string
+}
+
+// eventQueue collects events from network goroutines until the game
+// goroutine drains them at the start of its next Update
+type eventQueue struct {
+	mutex  sync.Mutex
+	events []event
+}
+
+// push adds an event, it never blocks on the game
+func (q *eventQueue) push(e event) {
+	q.mutex.Lock()
+	q.events = append(q.events, e)
+	q.mutex.Unlock()
+}
+
+// take returns the queued events and empties the queue
+func (q *eventQueue) take() []event {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	events := q.events
+	q.events = nil
+	return events
+}
+
+// Deliver queues a message received from the network, safe to call from
+// any goroutine. It is applied at the start of the next Update.
+func (g *Game) Deliver(data []byte) {
+	g.queue.push(event{data: data})
+}
+
+// Disconnect queues the departure of a peer, safe to call from any
+// goroutine. Its tank is removed RemoveDelay frames after the next Update.
+func (g *Game) Disconnect(id string) {
+	g.queue.push(event{left: id})
+}
+
+// drainEvents applies everything the network delivered since the last
+// frame, in the order it arrived. Only the game goroutine calls it.
+func (g *Game) drainEvents() {
+	for _, e := range g.queue.take() {
+		if e.left != "" {
+			g.mutex.Lock()
+			g.scheduleRemoval(e.left)
+			g.mutex.Unlock()
+			continue
+		}
+		g.HandleMessage(e.data)
+	}
+}
+
+// scheduleRemoval starts the countdown to removing a tank, must hold mutex
+func (g *Game) scheduleRemoval(id string) {
+	if p, exists := g.Players[id]; exists && p.removeIn == 0 {
+		p.removeIn = RemoveDelay
+	}
+}
+
+// updateRemovals counts down tanks on their way out and removes them,
+// must hold mutex
+func (g *Game) updateRemovals() {
+	for id, p := range g.Players {
+		if p.removeIn == 0 {
+			continue
+		}
+		if p.removeIn--; p.removeIn == 0 {
+			fmt.Println("Removing player:", id)
+			delete(g.Players, id)
+		}
+	}
+}
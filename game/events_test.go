@@ -0,0 +1,71 @@
+package game_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Delivered Messages Wait For Update**
+func TestDeliveredMessagesApplyOnUpdate(t *testing.T) {
+	gameInstance := &game.Game{
+		Players:         make(map[string]*game.Player),
+		LocalPlayerID:   "local",
+		LocalController: idle{},
+	}
+	gameInstance.Players["local"] = &game.Player{ID: "local", X: 400, Y: 300, Health: game.MaxHealth}
+
+	// Many network goroutines at once
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data, _ := json.Marshal(game.MovementMessage{Type: "move", ID: fmt.Sprintf("peer-%d", i), X: 100, Y: float64(20 + 25*i)})
+			gameInstance.Deliver(data)
+		}(i)
+	}
+	wg.Wait()
+
+	if len(gameInstance.Players) != 1 {
+		t.Fatalf("Expected queued messages to wait for the next Update, got %d players", len(gameInstance.Players))
+	}
+	gameInstance.Update()
+	if len(gameInstance.Players) != 21 {
+		t.Errorf("Expected every delivered peer after Update, got %d players", len(gameInstance.Players))
+	}
+}
+
+// ** Test Departures Without Goroutines**
+func TestEliminatedAndDisconnectedTanksAreRemoved(t *testing.T) {
+	gameInstance := &game.Game{
+		Players:         make(map[string]*game.Player),
+		LocalPlayerID:   "local",
+		LocalController: idle{},
+	}
+	gameInstance.Players["local"] = &game.Player{ID: "local", X: 400, Y: 300, Health: game.MaxHealth}
+	gameInstance.Players["victim"] = &game.Player{ID: "victim", X: 100, Y: 100, Health: game.MaxHealth}
+	gameInstance.Players["leaver"] = &game.Player{ID: "leaver", X: 100, Y: 500, Health: game.MaxHealth}
+
+	gameInstance.ApplyHit(game.HitMessage{VictimID: "victim", ShooterID: "local", Health: 0, Eliminated: true})
+	for i := 0; i < game.RemoveDelay/2; i++ {
+		gameInstance.Update()
+	}
+	gameInstance.Disconnect("leaver")
+	for i := 0; i < game.RemoveDelay/2; i++ {
+		gameInstance.Update()
+	}
+	if gameInstance.Players["victim"] != nil || gameInstance.Players["leaver"] == nil {
+		t.Fatalf("Expected the victim gone and the leaver still counting down")
+	}
+
+	for i := 0; i < game.RemoveDelay/2; i++ {
+		gameInstance.Update()
+	}
+	if gameInstance.Players["leaver"] != nil || gameInstance.Players["local"] == nil {
+		t.Errorf("Expected only the leaver removed, got %d players", len(gameInstance.Players))
+	}
+}
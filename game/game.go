@@ -1,12 +1,10 @@
 package game
 
 import (
-	"fmt"
 	"image/color"
 	"log"
 	"math"
 	"math/rand"
-	"net"
 	"sync"
 	"time"
 
@@ -31,7 +29,6 @@ const (
 )
 
 var (
-	mutex sync.Mutex
 	tankImage *ebiten.Image
 
 )
@@ -41,8 +38,22 @@ type Player struct {
 	ID       string  // Unique player ID
 	X, Y     float64 // Position
 	Angle    float64 // Facing direction
+	TurretAngle float64 // Direction the turret aims and shoots
+	Speed    float64 // Speed along the hull direction (tank movement)
 	Health   int     // Health bar
+	Shield   int     // Damage absorbed before health, from a pickup or regenerated
+	Score    int     // Eliminations this match (deathmatch)
+	Team     int     // Team number from 1, 0 without teams
+	fixedTeam bool   // A remote mesh player asked for its team, see keepsTeam
+	Weapon   string  // Weapon ID, "" for the blaster
 	cooldown int     // Shooting cooldown
+	shots    int     // Shots fired from the current magazine
+	reloading int    // Frames left until the magazine is full again
+	boost    int     // Frames of speed boost left
+	rapid    int     // Frames of rapid fire left
+	respawn  int     // Frames until an eliminated tank respawns (deathmatch)
+	sinceHit int     // Frames since the last damage, for shield regeneration
+	removeIn int     // Frames until an eliminated or departed tank is removed, 0 if staying
 	eliminated bool    // New: Marks player as eliminated
 	Image  *ebiten.Image // Store the player's tank sprite
 
@@ -56,12 +67,22 @@ type MovementMessage struct {
 	X     float64 `json:"x"`     // Updated X position
 	Y     float64 `json:"y"`     // Updated Y position
 	Angle float64 `json:"angle"` // Direction the player is facing
+	Turret float64 `json:"turret"` // Direction the turret is aiming
+	Speed float64 `json:"speed,omitempty"` // Speed along Angle (tank movement)
+	Weapon string `json:"weapon,omitempty"` // Weapon the player is holding
+	Team  int     `json:"team,omitempty"`  // Team the player is on
+	FixedTeam bool `json:"fixed_team,omitempty"` // The player asked for its team, auto-balance leaves it
+	Score int     `json:"score,omitempty"` // Eliminations, as tallied by the player's owner
+	Frame int     `json:"frame,omitempty"` // Frames the sender has played in a mesh match
+	Seq   uint32  `json:"seq"`   // Last input sequence applied to this state
 }
 
 // BulletMessage struct (sent to peers when a bullet is fired)
 type BulletMessage struct {
 	Type    string  `json:"type"`  // "bullet"
+	ID      uint32  `json:"id"`
 	OwnerID string  `json:"owner_id"`
+	Weapon  string  `json:"weapon,omitempty"` // Weapon that fired it, "" for the blaster
 	X       float64 `json:"x"`
 	Y       float64 `json:"y"`
 	VX      float64 `json:"vx"`
@@ -71,10 +92,17 @@ type BulletMessage struct {
 
 // Bullet struct (tracks owner)
 type Bullet struct {
+	ID       uint32 // Unique per owner, used to match hit confirmations
 	X, Y     float64
 	vx, vy   float64
 	Active   bool
 	OwnerID  string // ID of the player who fired it
+	Weapon   string // Weapon that fired it, decides damage, range and bounces
+
+	originX, originY float64 // Where it was at spawnTick, used in snapshots
+	spawnTick        uint32
+	traveled         float64 // Distance flown, for the weapon's range
+	bounces          int     // Ricochets so far
 }
 
 // Game struct (supports multiple players)
@@ -82,9 +110,58 @@ type Game struct {
 	Players       map[string]*Player // Stores all players
 	Bullets       []Bullet           // Stores all bullets
 	LocalPlayerID string             // ID of the local player
-	ActiveConnections map[string]net.Conn  // Stores active TCP connections to peers
 	SendUpdate func(interface{}) // Field for sending updates
-
+	SendTo     func(id string, data interface{}) // Field for sending to a single peer
+	Recorder   *Recorder // Logs network traffic and inputs to a replay file, may be nil
+	Client        bool // Connected to a dedicated server: send inputs, not state
+	Authoritative bool // Runs the referee simulation (dedicated server or elected host)
+	HostMode      bool   // Host-authoritative mesh: one elected peer referees
+	HostID        string // Currently elected host in HostMode
+	Clock         func() time.Time // Time source for peer timeouts, time.Now when nil
+	Spectator     bool   // Watches the match without a tank
+	Match         MatchConfig // Rules of the match, adopted from the authority's snapshots
+	Team          int    // Team the local player asks for, 0 to be auto-balanced
+
+	LocalController Controller            // Drives the local player instead of the keyboard (headless bots)
+	Bots            map[string]Controller // Extra computer players owned by this game, see AddBot
+	Controls        *Controls             // Keyboard, mouse and gamepad bindings, nil for the defaults
+	ControlsPath    string                // Where the controls screen saves changes, "" to keep them in memory
+	ShowHitboxes    bool                  // Outline hulls and bullet sweeps (HitboxKey toggles)
+
+	inputSeq      uint32        // Sequence number of the last local input
+	pendingInputs []PlayerInput // Local inputs not yet acknowledged by an authority
+	correctionX   float64       // Visual offset left over from the last reconciliation
+	correctionY   float64
+	bulletSeq     uint32 // ID of the last bullet fired locally
+	botSeq        map[string]uint32 // Sequence number of each bot's last input on an authority
+
+	tick        uint32            // Authoritative simulation step
+	lastInput   map[string]uint32 // Last input sequence applied per player
+	inputBudget map[string]int    // Inputs accepted per player this tick
+	acked       map[string]uint32 // Last snapshot each client acknowledged
+	history     [SnapshotHistory]SnapshotMessage
+
+	frame               int                  // Frames since start, paces heartbeats
+	lastHeard           map[string]time.Time // Last message from each peer (host mode)
+	joinSent            bool                 // Join sent to the current host
+	missingFromSnapshot bool                 // Host's last snapshot did not have us
+	spectators          map[string]time.Time // Spectators watching through us (host mode)
+
+	followID   string        // Player the spectator camera follows, "" for the whole arena
+	worldImage *ebiten.Image // Offscreen arena for the spectator camera
+
+	rebind rebindScreen // In-game controls menu (F1)
+
+	pickups []pickup // One per spawn point in PickupSpawns, nil without pickups
+	matchFrame int   // Frames played in a mesh deathmatch, for its time limit, shared through moves
+	teamPoints []int // Hill points per team (king of the hill)
+	indicators []hitIndicator // Recent hits on the local player, see drawIndicators
+	grid       grid           // Broad phase for bullet hits and tank collisions, rebuilt every step
+	nearby     []*Player      // Reused buffer for grid queries
+	queue      eventQueue     // Messages and departures from network goroutines, see Deliver
+
+	mutex sync.Mutex // Guards the world, every Game has its own
+	rng   *rand.Rand // Spawn points and weapon spread, see random
 }
 
 // LoadAssets loads the tank sprite
@@ -94,92 +171,69 @@ func LoadAssets() {
         log.Fatal("Failed to load tank sprite:", err)
     }
     tankImage = img
+    loadTurret()
 }
 
 
 func (g *Game) Update() error {
-	player, exists := g.Players[g.LocalPlayerID]
-    if !exists || player.eliminated { // Prevent updates for eliminated players
-        return nil
-    }
-
-	vx, vy := 0.0, 0.0 // Velocity
+	// Network goroutines only queue, the world changes on this goroutine
+	g.drainEvents()
 
-	if ebiten.IsKeyPressed(ebiten.KeyW) {
-		vy -= PlayerSpeed
+	if g.HostMode {
+		g.updateHost()
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyS) {
-		vy += PlayerSpeed
+	if g.Spectator {
+		g.updateSpectatorCamera()
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyA) {
-		vx -= PlayerSpeed
+
+	// An authority counts down respawns, shields and removals as part of its tick
+	g.mutex.Lock()
+	if !g.Authoritative {
+		g.updateMatch()
+		g.updateShields()
+		g.updateRemovals()
+		g.rebalanceTeams()
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyD) {
-		vx += PlayerSpeed
+	g.updateIndicators()
+	over := g.matchOver()
+	g.mutex.Unlock()
+	if over {
+		return nil
 	}
 
-	// Only send updates if movement occurred
-	if vx != 0 || vy != 0 {
-		player.Angle = math.Atan2(vy, vx)
-		player.X += vx
-		player.Y += vy
+	if g.LocalController == nil {
+		g.updateHitboxKey()
+	}
 
-		// Prevent moving off-screen
-		if player.X < 0 {
-			player.X = 0
-		}
-		if player.X > ScreenWidth-PlayerSize {
-			player.X = ScreenWidth - PlayerSize
-		}
-		if player.Y < 0 {
-			player.Y = 0
-		}
-		if player.Y > ScreenHeight-PlayerSize {
-			player.Y = ScreenHeight - PlayerSize
+	// Eliminated players only watch, the match keeps running
+	player, exists := g.Players[g.LocalPlayerID]
+	if exists && !player.eliminated {
+		var input PlayerInput
+		if g.LocalController != nil {
+			input = g.LocalController.NextInput(g, player)
+		} else if g.updateRebind() {
+			// The controls screen has the keyboard, the tank sits still
+			input = PlayerInput{Aim: player.TurretAngle}
+		} else {
+			input = g.controls().Read(player)
 		}
 
-		// Send movement update to peers
-		g.sendMovementUpdate(player)
+		// Predict local movement immediately, peers or an authority confirm it later
+		g.ApplyLocalInput(input)
+		g.decayCorrection()
 	}
+	g.UpdateBots()
 
-	// Shooting Mechanism
-	if g.Players[g.LocalPlayerID].cooldown > 0 {
-		g.Players[g.LocalPlayerID].cooldown--
+	// The host runs the match for everyone, others just move bullets
+	if !g.Authoritative {
+		g.mutex.Lock()
+		g.updatePickups()
+		g.mutex.Unlock()
 	}
-	if ebiten.IsKeyPressed(ebiten.KeySpace) && g.Players[g.LocalPlayerID].cooldown == 0 {
-		g.ShootBullet()
-		g.Players[g.LocalPlayerID].cooldown = ShotCooldown
-	}
-
-
-	// Bullet update logic
-	for i := range g.Bullets {
-		if g.Bullets[i].Active {
-			g.Bullets[i].X += g.Bullets[i].vx
-			g.Bullets[i].Y += g.Bullets[i].vy
-
-			// Bullet out of bounds check
-			if g.Bullets[i].X < 0 || g.Bullets[i].X > ScreenWidth || g.Bullets[i].Y < 0 || g.Bullets[i].Y > ScreenHeight {
-				g.Bullets[i].Active = false
-				continue
-			}
-
-			// Bullet collision with other players
-			for pid, target := range g.Players {
-				if pid != g.Bullets[i].OwnerID && CheckCollision(g.Bullets[i], target, g) {
-					g.Bullets[i].Active = false
-					target.Health -= DamageAmount // Apply damage
-					g.Players[pid] = target // Update player health
-
-					// Elimination check
-					if target.Health <= 0 {
-						g.RemovePlayerAfterDelay(pid)
-						// delete(g.Players, pid) // Remove eliminated player
-						fmt.Println("Player", pid, "eliminated!")
-					}
-				}
-			}
-		}
+	if g.Authoritative {
+		g.hostStep()
+	} else {
+		g.UpdateBullets()
 	}
 
 	return nil
@@ -192,7 +246,16 @@ func (g *Game) sendMovementUpdate(player *Player) {
 		X:     player.X,
 		Y:     player.Y,
 		Angle: player.Angle,
+		Turret: player.TurretAngle,
+		Speed: player.Speed,
+		Weapon: player.Weapon,
+		Team:  player.Team,
+		FixedTeam: g.keepsTeam(player),
+		Score: player.Score,
+		Frame: g.matchFrame,
+		Seq:   g.inputSeq,
 	}
+	g.Recorder.Record(RecordOut, message)
 
 	// Call the injected function
 	if g.SendUpdate != nil {
@@ -201,13 +264,35 @@ func (g *Game) sendMovementUpdate(player *Player) {
 }
 
 func (g *Game) UpdatePlayerPosition(msg MovementMessage) {
-    mutex.Lock()
-    defer mutex.Unlock()
+    g.mutex.Lock()
+    defer g.mutex.Unlock()
+
+    player := g.applyPlayerState(msg)
+
+    // In the mesh a tank's owner keeps its score and knows whether it chose
+    // its team, and the match clock is the one of the peer that has played
+    // longest, so every peer agrees
+    if msg.ID != g.LocalPlayerID {
+        player.Score = msg.Score
+        player.fixedTeam = msg.FixedTeam
+    }
+    if msg.Frame > g.matchFrame {
+        g.matchFrame = msg.Frame
+    }
+}
 
-    if player, exists := g.Players[msg.ID]; exists {
+func (g *Game) applyPlayerState(msg MovementMessage) *Player {
+    if player, exists := g.Players[msg.ID]; exists && msg.ID == g.LocalPlayerID {
+        // Authoritative state for our own tank: rewind and replay inputs
+        g.reconcile(player, msg)
+    } else if exists {
         player.X = msg.X
         player.Y = msg.Y
         player.Angle = msg.Angle
+        player.TurretAngle = msg.Turret
+        player.Speed = msg.Speed
+        player.Weapon = msg.Weapon
+        player.Team = msg.Team
     } else {
         // **Create new player if they don't exist**
         g.Players[msg.ID] = &Player{
@@ -215,80 +300,85 @@ func (g *Game) UpdatePlayerPosition(msg MovementMessage) {
             X:      msg.X,
             Y:      msg.Y,
             Angle:  msg.Angle,
+            TurretAngle: msg.Turret,
+            Speed:  msg.Speed,
+            Weapon: msg.Weapon,
+            Team:   msg.Team,
             Health: MaxHealth,
         }
     }
+    return g.Players[msg.ID]
 }
 
 // **Bullet Collision Check**
+// Sweeps the bullet's last step against the rotated hull, centered on the
+// tank like its sprite, so fast bullets can't skip through it. Only tests
+// for contact, damage is applied by the victim's client (see ApplyHit)
 func CheckCollision(b Bullet, p *Player, g *Game) bool {
-	if b.X > p.X && b.X < p.X+PlayerSize && b.Y > p.Y && b.Y < p.Y+PlayerSize {
-		p.Health -= DamageAmount
-		fmt.Println("Player", p.ID, "hit! New health:", p.Health)
-
-		if p.Health <= 0 && !p.eliminated {
-			fmt.Println("Player", p.ID, "eliminated!")
-			p.eliminated = true // Mark as eliminated
-			go g.RemovePlayerAfterDelay(p.ID) // Remove after delay
-		}
-		return true
-	}
-	return false
-}
-
-func (g *Game) RemovePlayerAfterDelay(playerID string) {
-	time.Sleep(3 * time.Second) // Wait 3 seconds before removal
-
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	if _, exists := g.Players[playerID]; exists {
-		fmt.Println("Removing player:", playerID)
-		delete(g.Players, playerID) // Now safe to remove
-	}
+	_, hit := bulletHit(b, p)
+	return hit
 }
 
 // Shoot a bullet and send an update to peers
 func (g *Game) ShootBullet() {
-	vx := BulletSpeed * math.Cos(g.Players[g.LocalPlayerID].Angle)
-	vy := BulletSpeed * math.Sin(g.Players[g.LocalPlayerID].Angle)
-	newBullet := Bullet{
-		X:       g.Players[g.LocalPlayerID].X + PlayerSize/2,
-		Y:       g.Players[g.LocalPlayerID].Y + PlayerSize/2,
-		vx:      vx, 
-		vy:      vy,
-		Active:  true,
-		OwnerID: g.LocalPlayerID, // Identify shooter
-	}
+	g.fireBullet(g.Players[g.LocalPlayerID])
+}
 
-	g.Bullets = append(g.Bullets, newBullet)
+// fireBullet spawns the projectiles of one shot from a player's weapon and
+// announces each of them, spread is decided here so peers need no shared
+// randomness
+func (g *Game) fireBullet(owner *Player) {
+	w := WeaponByID(owner.Weapon)
+	for i := 0; i < w.Pellets; i++ {
+		angle := owner.TurretAngle + g.spread(w)
+		g.bulletSeq++
+		newBullet := Bullet{
+			ID:      g.bulletSeq,
+			X:       owner.X, // From the turret pivot, the center of the tank
+			Y:       owner.Y,
+			vx:      w.Speed * math.Cos(angle),
+			vy:      w.Speed * math.Sin(angle),
+			Active:  true,
+			OwnerID: owner.ID, // Identify shooter
+			Weapon:  owner.Weapon,
+		}
+		newBullet.originX, newBullet.originY = newBullet.X, newBullet.Y
+		newBullet.spawnTick = g.tick
 
-	// Send bullet data to all peers
-	if g.SendUpdate != nil {
+		g.Bullets = append(g.Bullets, newBullet)
+
+		// Send bullet data to all peers
 		bulletUpdate := BulletMessage{
 			Type:    "bullet",
+			ID:      newBullet.ID,
 			OwnerID: newBullet.OwnerID,
+			Weapon:  newBullet.Weapon,
 			X:       newBullet.X,
 			Y:       newBullet.Y,
 			VX:      newBullet.vx,
 			VY:      newBullet.vy,
 		}
-		g.SendUpdate(bulletUpdate)
+		g.Recorder.Record(RecordOut, bulletUpdate)
+		if g.SendUpdate != nil {
+			g.SendUpdate(bulletUpdate)
+		}
 	}
 }
 
 // Add a bullet from a received peer message
 func (g *Game) AddBulletFromPeer(msg BulletMessage) {
-	mutex.Lock()
-	defer mutex.Unlock()
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
 
 	newBullet := Bullet{
+		ID:      msg.ID,
 		X:       msg.X,
 		Y:       msg.Y,
 		vx:      msg.VX,
 		vy:      msg.VY,
 		Active:  true,
 		OwnerID: msg.OwnerID,
+		Weapon:  msg.Weapon,
 	}
 
 	g.Bullets = append(g.Bullets, newBullet)
@@ -296,6 +386,24 @@ func (g *Game) AddBulletFromPeer(msg BulletMessage) {
 
 // Draw renders everything
 func (g *Game) Draw(screen *ebiten.Image) {
+	if g.Spectator {
+		g.drawSpectator(screen)
+		return
+	}
+	g.drawWorld(screen)
+	g.drawIndicators(screen)
+	g.drawHUD(screen)
+	g.drawScoreboard(screen)
+	if g.rebind.open {
+		g.drawRebind(screen)
+	}
+}
+
+// drawWorld renders players and bullets in arena coordinates
+func (g *Game) drawWorld(screen *ebiten.Image) {
+	g.drawHill(screen)
+	g.drawPickups(screen)
+
 	for _, player := range g.Players { // Draw all players
 
 		if player.Image == nil {
@@ -307,9 +415,13 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		op.GeoM.Scale(scale, scale) // Scale the sprite
         op.GeoM.Translate(-float64(player.Image.Bounds().Dx())*scale/2, -float64(player.Image.Bounds().Dy())*scale/2) // Center the rotation
         op.GeoM.Rotate(player.Angle) // Rotate the sprite
-        op.GeoM.Translate(player.X, player.Y) // Position the sprite at the player's location
+        drawX, drawY := g.renderPosition(player)
+        op.GeoM.Translate(drawX, drawY) // Position the sprite at the player's location
+        tintTeam(op, player.Team)
 
         screen.DrawImage(player.Image, op) // Render the tank sprite
+        drawTurret(screen, player, drawX, drawY)
+        drawEffects(screen, player, drawX, drawY)
 
 		if player.eliminated { // Skip eliminated players
 			continue
@@ -322,9 +434,13 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	// Draw bullets
 	for _, b := range g.Bullets {
 		if b.Active {
-			ebitenutil.DrawRect(screen, b.X - 12, b.Y - 12, BulletSize, BulletSize, color.RGBA{255, 255, 0, 255})
+			ebitenutil.DrawRect(screen, b.X-BulletSize/2, b.Y-BulletSize/2, BulletSize, BulletSize, WeaponByID(b.Weapon).Color)
 		}
 	}
+
+	if g.ShowHitboxes {
+		g.drawHitboxes(screen)
+	}
 }
 
 // **Draw Health Bar Above Players**
@@ -337,8 +453,9 @@ func (g *Game) drawHealthBar(screen *ebiten.Image, player *Player) {
     barCurrentWidth := HealthBarWidth * healthPercentage
 
     // Calculate the position of the health bar based on the player's rotation
-    barX := player.X - barCurrentWidth/2
-    barY := player.Y - tankHeight/2 - 10 // Position above player (adjust as needed)
+    drawX, drawY := g.renderPosition(player)
+    barX := drawX - barCurrentWidth/2
+    barY := drawY - tankHeight/2 - 10 // Position above player (adjust as needed)
 
 	// Change health bar color based on health
 	var healthColor color.Color
@@ -361,19 +478,28 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return ScreenWidth, ScreenHeight
 }
 
-// **Generate a Unique Spawn Location**
-func getRandomSpawn(existingPlayers map[string]*Player) (float64, float64) {
-	rand.Seed(time.Now().UnixNano()) // Seed randomness
+// random returns the game's own random source, seeded from the clock on
+// first use so games in one process don't share or reset each other's
+func (g *Game) random() *rand.Rand {
+	if g.rng == nil {
+		g.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return g.rng
+}
 
+// **Generate a Unique Spawn Location**
+func (g *Game) getRandomSpawn(existingPlayers map[string]*Player) (float64, float64) {
+	rng := g.random()
 	for {
-		x := rand.Float64()*(ScreenWidth-PlayerSize) + PlayerSize/2
-		y := rand.Float64()*(ScreenHeight-PlayerSize) + PlayerSize/2
+		x := rng.Float64()*(ScreenWidth-HullLength) + HullLength/2
+		y := rng.Float64()*(ScreenHeight-HullLength) + HullLength/2
 
-		// Ensure new spawn is not too close to an existing player
+		// Ensure new spawn is not too close to an existing player, so tanks
+		// never start inside each other
 		overlapping := false
 		for _, p := range existingPlayers {
 			dist := (p.X-x)*(p.X-x) + (p.Y-y)*(p.Y-y)
-			if dist < (PlayerSize * PlayerSize) {
+			if dist < (HullLength * HullLength) {
 				overlapping = true
 				break
 			}
@@ -389,8 +515,18 @@ func (g *Game) MainGame(game *Game) {
 	// Load tank sprite
 	LoadAssets()
 
+	// Spectators watch without a tank of their own
+	if game.Spectator {
+		ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
+		ebiten.SetWindowTitle("2D Battle Royale - Spectating")
+		if err := ebiten.RunGame(game); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Get a random spawn position
-	spawnX, spawnY := getRandomSpawn(game.Players)
+	spawnX, spawnY := game.getRandomSpawn(game.Players)
 
 	// Create the local player with a unique ID and random spawn position
 	game.Players[game.LocalPlayerID] = &Player{
@@ -398,6 +534,7 @@ func (g *Game) MainGame(game *Game) {
 		X:      spawnX,
 		Y:      spawnY,
 		Health: MaxHealth,
+		Team:   game.pickTeam(game.Team),
 	}
 
 	// Set window properties
@@ -408,4 +545,30 @@ func (g *Game) MainGame(game *Game) {
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
 	}
-}
\ No newline at end of file
+}
+
+// RunHeadless plays the match without a window, e.g. for bot peers and
+// load tests. The local player is usually driven by LocalController.
+func (g *Game) RunHeadless(stop <-chan struct{}) {
+	g.mutex.Lock()
+	spawnX, spawnY := g.getRandomSpawn(g.Players)
+	g.Players[g.LocalPlayerID] = &Player{
+		ID:     g.LocalPlayerID,
+		X:      spawnX,
+		Y:      spawnY,
+		Health: MaxHealth,
+		Team:   g.pickTeam(g.Team),
+	}
+	g.mutex.Unlock()
+
+	ticker := time.NewTicker(time.Second / 60)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			g.Update()
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package game_test
 
 import (
+	"encoding/json"
+	"math"
 	"testing"
+	"time"
 
 	"shooter/game" // Import the actual package
 )
@@ -47,7 +50,7 @@ func TestShootBullet(t *testing.T) {
 
 	// Verify bullet properties
 	bullet := gameInstance.Bullets[0]
-	if bullet.X != 100+game.PlayerSize/2 || bullet.Y != 100+game.PlayerSize/2 {
+	if bullet.X != 100 || bullet.Y != 100 {
 		t.Errorf("Bullet spawned at incorrect location (%f, %f)", bullet.X, bullet.Y)
 	}
 }
@@ -70,8 +73,474 @@ func TestBulletCollision(t *testing.T) {
 		t.Errorf("Expected collision but none occurred")
 	}
 
-	// Verify player health reduction
-	if gameInstance.Players[playerID].Health != 95 {
-		t.Errorf("Expected health 95, but got %d", gameInstance.Players[playerID].Health)
+	// Collision alone must not apply damage, the victim's client does that
+	if gameInstance.Players[playerID].Health != 100 {
+		t.Errorf("Expected health 100, but got %d", gameInstance.Players[playerID].Health)
 	}
-}
\ No newline at end of file
+}
+
+// ** Test Victim Confirms Hit**
+func TestVictimConfirmsHit(t *testing.T) {
+	var sent []interface{}
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "victim",
+		SendUpdate:    func(msg interface{}) { sent = append(sent, msg) },
+	}
+	gameInstance.Players["victim"] = &game.Player{ID: "victim", X: 50, Y: 50, Angle: math.Pi, Health: 100} // Facing the shot
+	gameInstance.AddBulletFromPeer(game.BulletMessage{ID: 7, OwnerID: "shooter", X: 50, Y: 55, VX: 4})
+
+	gameInstance.UpdateBullets()
+
+	if gameInstance.Players["victim"].Health != 100-game.DamageAmount {
+		t.Errorf("Expected health %d, but got %d", 100-game.DamageAmount, gameInstance.Players["victim"].Health)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 hit message, got %d", len(sent))
+	}
+	hit, ok := sent[0].(game.HitMessage)
+	if !ok || hit.VictimID != "victim" || hit.ShooterID != "shooter" || hit.BulletID != 7 {
+		t.Errorf("Unexpected hit message %+v", sent[0])
+	}
+}
+
+// ** Test Bullet Message Handling**
+func TestHandleBulletMessage(t *testing.T) {
+	gameInstance := &game.Game{Players: make(map[string]*game.Player), HostMode: true}
+
+	gameInstance.HandleMessage([]byte(`{"type":"bullet","id":3,"owner_id":"player2","x":10,"y":20,"vx":4,"vy":0}`))
+
+	if len(gameInstance.Bullets) != 1 || gameInstance.Bullets[0].ID != 3 {
+		t.Errorf("Expected the bullet from player2 to be added, got %+v", gameInstance.Bullets)
+	}
+}
+
+// ** Test Remote Hit Waits For Victim**
+func TestRemoteHitWaitsForVictim(t *testing.T) {
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "shooter",
+	}
+	gameInstance.Players["shooter"] = &game.Player{ID: "shooter", X: 300, Y: 300, Health: 100}
+	gameInstance.Players["victim"] = &game.Player{ID: "victim", X: 50, Y: 50, Health: 100}
+	gameInstance.Bullets = append(gameInstance.Bullets, game.Bullet{ID: 1, X: 55, Y: 55, Active: true, OwnerID: "shooter"})
+
+	gameInstance.UpdateBullets()
+
+	if gameInstance.Bullets[0].Active {
+		t.Errorf("Expected bullet to stop on contact")
+	}
+	if gameInstance.Players["victim"].Health != 100 {
+		t.Errorf("Shooter applied damage locally, health %d", gameInstance.Players["victim"].Health)
+	}
+
+	gameInstance.ApplyHit(game.HitMessage{VictimID: "victim", ShooterID: "shooter", BulletID: 1, Health: 80})
+	if gameInstance.Players["victim"].Health != 80 {
+		t.Errorf("Expected confirmed health 80, got %d", gameInstance.Players["victim"].Health)
+	}
+}
+// ** Test Prediction Reconciliation**
+func TestReconcileReplaysUnacknowledgedInputs(t *testing.T) {
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "player1",
+	}
+	gameInstance.Players["player1"] = &game.Player{ID: "player1", X: 100, Y: 100, Health: game.MaxHealth}
+
+	// Predict three steps to the right
+	for i := 0; i < 3; i++ {
+		gameInstance.ApplyLocalInput(game.PlayerInput{MoveX: 1})
+	}
+	if gameInstance.Players["player1"].X != 100+3*game.PlayerSpeed {
+		t.Fatalf("Expected predicted X %d, got %f", 100+3*game.PlayerSpeed, gameInstance.Players["player1"].X)
+	}
+
+	// Authority has only processed the first input, and from a different start
+	gameInstance.UpdatePlayerPosition(game.MovementMessage{ID: "player1", X: 50, Y: 100, Seq: 1})
+
+	if gameInstance.Players["player1"].X != 50+2*game.PlayerSpeed {
+		t.Errorf("Expected reconciled X %d, got %f", 50+2*game.PlayerSpeed, gameInstance.Players["player1"].X)
+	}
+	if gameInstance.PendingInputs() != 2 {
+		t.Errorf("Expected 2 pending inputs, got %d", gameInstance.PendingInputs())
+	}
+}
+
+// ** Test Server Input Validation**
+func TestServerValidatesInputs(t *testing.T) {
+	server := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+	}
+	server.AddPlayer("client1")
+	startX := server.Players["client1"].X
+
+	// Oversized movement is clamped and replayed sequence numbers are ignored
+	server.ApplyRemoteInput(game.InputMessage{ID: "client1", PlayerInput: game.PlayerInput{Seq: 1, MoveX: 50}})
+	server.ApplyRemoteInput(game.InputMessage{ID: "client1", PlayerInput: game.PlayerInput{Seq: 1, MoveX: 1}})
+
+	snap := server.Tick()
+	if len(snap.Players) != 1 || snap.Players[0].Seq != 1 {
+		t.Fatalf("Unexpected snapshot %+v", snap)
+	}
+	moved := snap.Players[0].X - startX
+	if moved > game.PlayerSpeed {
+		t.Errorf("Expected at most %d units of movement, got %f", game.PlayerSpeed, moved)
+	}
+
+	// Nonsense input is neither applied nor acknowledged
+	server.ApplyRemoteInput(game.InputMessage{ID: "client1", PlayerInput: game.PlayerInput{Seq: 2, MoveX: math.NaN()}})
+	if next := server.Tick(); next.Players[0].Seq != 1 || math.IsNaN(next.Players[0].X) {
+		t.Errorf("Expected the NaN input to be rejected before its ack, got seq %d", next.Players[0].Seq)
+	}
+
+	// A client applies the snapshot as the new world state
+	client := &game.Game{Players: make(map[string]*game.Player), LocalPlayerID: "client1", Client: true}
+	client.ApplySnapshot(snap)
+	if client.Players["client1"] == nil || client.Players["client1"].X != snap.Players[0].X {
+		t.Errorf("Client did not apply snapshot position")
+	}
+}
+
+// ** Test Host Election**
+func TestHostElectionAndMigration(t *testing.T) {
+	var sent []interface{}
+	now := time.Now()
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "peer-b",
+		HostMode:      true,
+		SendUpdate:    func(msg interface{}) { sent = append(sent, msg) },
+		Clock:         func() time.Time { return now },
+	}
+	gameInstance.Players["peer-b"] = &game.Player{ID: "peer-b", X: 100, Y: 100, Health: game.MaxHealth}
+
+	// Alone, we referee the match ourselves
+	if host := gameInstance.ElectHost(); host != "peer-b" {
+		t.Errorf("Expected to host alone, got %s", host)
+	}
+
+	// A peer with a lower ID shows up and takes over
+	gameInstance.HandleMessage([]byte(`{"type":"heartbeat","id":"peer-a"}`))
+	if host := gameInstance.ElectHost(); host != "peer-a" {
+		t.Errorf("Expected peer-a to be elected, got %s", host)
+	}
+	gameInstance.Update()
+	if gameInstance.Authoritative || !gameInstance.Client {
+		t.Errorf("Expected to become a client of peer-a")
+	}
+
+	// Snapshots from the host are applied, others ignored
+	gameInstance.HandleMessage([]byte(`{"type":"snapshot","host":"peer-a","tick":5,"players":[{"id":"peer-a","x":10,"y":10,"health":100},{"id":"peer-b","x":120,"y":100,"health":90}]}`))
+	gameInstance.HandleMessage([]byte(`{"type":"snapshot","host":"peer-c","tick":6,"players":[]}`))
+	if gameInstance.Players["peer-b"].Health != 90 || gameInstance.Players["peer-a"] == nil {
+		t.Fatalf("Expected host snapshot to be applied")
+	}
+
+	// The host goes quiet, we take over and keep the match state
+	now = now.Add(game.HostTimeout)
+	gameInstance.Update()
+	if !gameInstance.Authoritative || gameInstance.HostID != "peer-b" {
+		t.Errorf("Expected peer-b to take over as host")
+	}
+	if gameInstance.Players["peer-b"].Health != 90 {
+		t.Errorf("Expected match state to survive migration")
+	}
+}
+
+// ** Test Host Authority**
+func TestClientIgnoresPeerStateUnderHost(t *testing.T) {
+	now := time.Now()
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "peer-b",
+		HostMode:      true,
+		Clock:         func() time.Time { return now },
+	}
+	gameInstance.Players["peer-b"] = &game.Player{ID: "peer-b", X: 100, Y: 100, Health: game.MaxHealth}
+	gameInstance.HandleMessage([]byte(`{"type":"heartbeat","id":"peer-a"}`))
+	gameInstance.Update()
+	if !gameInstance.Client || gameInstance.HostID != "peer-a" {
+		t.Fatalf("Expected to become a client of peer-a")
+	}
+
+	// Another peer claims to have eliminated us and moves itself around
+	gameInstance.HandleMessage([]byte(`{"type":"hit","victim_id":"peer-b","shooter_id":"peer-c","bullet_id":1,"health":0,"eliminated":true}`))
+	gameInstance.HandleMessage([]byte(`{"type":"move","id":"peer-c","x":10,"y":10}`))
+	if player := gameInstance.Players["peer-b"]; player.Health != game.MaxHealth || player.Eliminated() {
+		t.Errorf("Expected the forged hit to be ignored, health %d", player.Health)
+	}
+	if _, exists := gameInstance.Players["peer-c"]; exists {
+		t.Errorf("Expected the peer's own move to be ignored")
+	}
+}
+
+// ** Test Record And Replay**
+func TestRecordAndReplay(t *testing.T) {
+	path := t.TempDir() + "/match.replay"
+	recorder, err := game.NewRecorder(path, "player1")
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "player1",
+		Recorder:      recorder,
+	}
+	gameInstance.Players["player1"] = &game.Player{ID: "player1", X: 100, Y: 100, Health: game.MaxHealth}
+
+	gameInstance.ApplyLocalInput(game.PlayerInput{MoveX: 1})
+	gameInstance.HandleMessage([]byte(`{"type":"move","id":"player2","x":300,"y":200,"angle":0}`))
+	gameInstance.HandleMessage([]byte(`{"type":"hit","victim_id":"player2","shooter_id":"player1","bullet_id":1,"health":60}`))
+	recorder.Close()
+
+	events, err := game.LoadReplay(path)
+	if err != nil {
+		t.Fatalf("Failed to load replay: %v", err)
+	}
+	// start, input, move out, move in, hit in
+	if len(events) != 5 {
+		t.Fatalf("Expected 5 events, got %d", len(events))
+	}
+
+	replay := game.NewReplay(events)
+	if replay.PlayerID != "player1" {
+		t.Errorf("Expected recording player player1, got %s", replay.PlayerID)
+	}
+	replay.Seek(replay.Duration())
+
+	world := replay.World()
+	if world.Players["player1"] == nil || world.Players["player1"].X != 100+game.PlayerSpeed {
+		t.Errorf("Expected recorded player at X %d", 100+game.PlayerSpeed)
+	}
+	if world.Players["player2"] == nil || world.Players["player2"].Health != 60 {
+		t.Errorf("Expected player2 replayed with health 60")
+	}
+
+	// Seeking back rewinds the world
+	replay.Seek(0)
+	if len(replay.World().Players) != 0 {
+		t.Errorf("Expected an empty world after seeking to the start")
+	}
+}
+
+// ** Test Replay Of A Client**
+func TestReplayOfClientMatch(t *testing.T) {
+	path := t.TempDir() + "/client.replay"
+	recorder, err := game.NewRecorder(path, "client")
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+
+	server := &game.Game{Players: make(map[string]*game.Player), Authoritative: true}
+	server.AddPlayer("client")
+	server.AddPlayer("other")
+	var acks [][]byte
+	client := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "client",
+		Client:        true,
+		Recorder:      recorder,
+		SendUpdate: func(msg interface{}) {
+			data, _ := json.Marshal(msg)
+			acks = append(acks, data)
+		},
+	}
+
+	// A full snapshot, then deltas against what the client acked
+	for i := 0; i < 3; i++ {
+		server.Players["other"].X = 100 + float64(i)*10
+		data, _ := json.Marshal(server.SnapshotFor("client", server.Tick()))
+		client.HandleMessage(data)
+		for _, ack := range acks {
+			server.HandleMessage(ack)
+		}
+		acks = nil
+	}
+	recorder.Close()
+
+	events, err := game.LoadReplay(path)
+	if err != nil {
+		t.Fatalf("Failed to load replay: %v", err)
+	}
+	deltas := 0
+	for _, event := range events {
+		var envelope struct{ Type string }
+		json.Unmarshal(event.Data, &envelope)
+		if envelope.Type == "delta" {
+			deltas++
+		}
+	}
+	if deltas != 2 {
+		t.Errorf("Expected the snapshot and both deltas recorded, got %d deltas in %d events", deltas, len(events))
+	}
+
+	replay := game.NewReplay(events)
+	replay.Seek(replay.Duration())
+	world := replay.World()
+	if world.Players["client"] == nil || world.Players["other"] == nil || world.Players["other"].X != 120 {
+		t.Errorf("Expected both players replayed from the snapshots, got %+v", world.Players)
+	}
+}
+
+// ** Test Games Keep Their Own Lock**
+func TestGamesInOneProcessDontShareALock(t *testing.T) {
+	other := &game.Game{Players: make(map[string]*game.Player), LocalPlayerID: "peer-b"}
+	gameInstance := &game.Game{
+		Players:         make(map[string]*game.Player),
+		LocalPlayerID:   "peer-a",
+		LocalController: idle{},
+		Match:           game.MatchConfig{Mode: game.DeathmatchMode, Teams: 2},
+		SendUpdate: func(msg interface{}) {
+			// Straight into the other game, while this one holds its lock
+			data, _ := json.Marshal(msg)
+			other.HandleMessage(data)
+		},
+	}
+	gameInstance.Players["peer-a"] = &game.Player{ID: "peer-a", X: 100, Y: 100, Health: game.MaxHealth, Team: 1}
+	gameInstance.Players["peer-0"] = &game.Player{ID: "peer-0", X: 300, Y: 300, Health: game.MaxHealth, Team: 1}
+
+	done := make(chan struct{})
+	go func() {
+		gameInstance.Update() // Rebalancing moves peer-a to team 2 and tells the other game
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected games in one process not to block each other")
+	}
+	if p := other.Players["peer-a"]; p == nil || p.Team != 2 {
+		t.Errorf("Expected the other game to hear peer-a's new team")
+	}
+}
+
+// ** Test Spectator**
+func TestSpectatorNeverPlays(t *testing.T) {
+	var sent []interface{}
+	spectator := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "viewer",
+		Spectator:     true,
+		HostMode:      true,
+		SendUpdate:    func(msg interface{}) { sent = append(sent, msg) },
+	}
+
+	// Alone, a spectator does not referee a match
+	if host := spectator.ElectHost(); host != "" {
+		t.Errorf("Expected no host, got %s", host)
+	}
+
+	spectator.HandleMessage([]byte(`{"type":"heartbeat","id":"peer-a"}`))
+	for i := 0; i < game.HeartbeatInterval; i++ {
+		spectator.Update()
+	}
+	spectator.HandleMessage([]byte(`{"type":"snapshot","host":"peer-a","tick":1,"players":[{"id":"peer-a","x":10,"y":10,"health":100}]}`))
+
+	if spectator.Players["viewer"] != nil || spectator.Authoritative {
+		t.Errorf("Spectator must not have a tank or referee")
+	}
+	if spectator.Players["peer-a"] == nil {
+		t.Errorf("Spectator should see other players")
+	}
+	for _, msg := range sent {
+		switch msg.(type) {
+		case game.MovementMessage, game.InputMessage, game.JoinMessage, game.HeartbeatMessage:
+			t.Errorf("Spectator sent %T", msg)
+		}
+	}
+}
+
+// ** Test Turret Aiming**
+func TestTurretAimsIndependently(t *testing.T) {
+	var sent []interface{}
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "player1",
+		SendUpdate:    func(msg interface{}) { sent = append(sent, msg) },
+	}
+	gameInstance.Players["player1"] = &game.Player{ID: "player1", X: 100, Y: 100, Health: game.MaxHealth}
+
+	// Standing still, aiming straight down and firing
+	gameInstance.ApplyLocalInput(game.PlayerInput{Aim: math.Pi / 2, Fire: true})
+
+	player := gameInstance.Players["player1"]
+	if player.TurretAngle != math.Pi/2 || player.Angle != 0 {
+		t.Errorf("Expected turret at %f and hull at 0, got %f and %f", math.Pi/2, player.TurretAngle, player.Angle)
+	}
+	if len(gameInstance.Bullets) != 1 {
+		t.Fatalf("Expected a bullet to be fired while stationary")
+	}
+	if vx, vy := gameInstance.Bullets[0].Velocity(); math.Abs(vx) > 1e-9 || vy != game.BulletSpeed {
+		t.Errorf("Expected the bullet to follow the turret, got velocity (%f, %f)", vx, vy)
+	}
+
+	move, ok := sent[0].(game.MovementMessage)
+	if !ok || move.Turret != math.Pi/2 {
+		t.Errorf("Expected the turret angle to be synchronized, got %+v", sent[0])
+	}
+}
+
+// ** Test Tank Movement**
+func TestTankMovementAcceleratesAndCoasts(t *testing.T) {
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "player1",
+		Match:         game.MatchConfig{Movement: game.TankMovement},
+	}
+	gameInstance.Players["player1"] = &game.Player{ID: "player1", X: 100, Y: 300, Health: game.MaxHealth}
+	player := gameInstance.Players["player1"]
+
+	// Full throttle builds speed gradually up to the limit
+	gameInstance.ApplyLocalInput(game.PlayerInput{MoveY: -1})
+	if player.Speed != game.TankAcceleration {
+		t.Errorf("Expected speed %f after one frame, got %f", game.TankAcceleration, player.Speed)
+	}
+	for i := 0; i < 100; i++ {
+		gameInstance.ApplyLocalInput(game.PlayerInput{MoveY: -1})
+	}
+	if player.Speed != game.TankMaxSpeed {
+		t.Errorf("Expected top speed %f, got %f", game.TankMaxSpeed, player.Speed)
+	}
+
+	// Releasing the throttle coasts to a stop
+	x := player.X
+	gameInstance.ApplyLocalInput(game.PlayerInput{})
+	if player.X <= x || player.Speed >= game.TankMaxSpeed {
+		t.Errorf("Expected the tank to coast and slow down, got x %f speed %f", player.X, player.Speed)
+	}
+	for i := 0; i < 100; i++ {
+		gameInstance.ApplyLocalInput(game.PlayerInput{})
+	}
+	if player.Speed != 0 {
+		t.Errorf("Expected the tank to stop, got speed %f", player.Speed)
+	}
+
+	// Steering turns the hull in place
+	gameInstance.ApplyLocalInput(game.PlayerInput{MoveX: 1})
+	if player.Angle != game.TankTurnRate {
+		t.Errorf("Expected the hull to turn by %f, got %f", game.TankTurnRate, player.Angle)
+	}
+}
+
+// ** Test Match Config From Authority**
+func TestClientAdoptsServerMovement(t *testing.T) {
+	server := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+		Match:         game.MatchConfig{Movement: game.TankMovement},
+	}
+	server.AddPlayer("player1")
+
+	client := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "player1",
+		Client:        true,
+	}
+	client.ApplySnapshot(server.Tick())
+
+	if client.Match.Movement != game.TankMovement {
+		t.Errorf("Expected the client to use the server's movement model, got %q", client.Match.Movement)
+	}
+}
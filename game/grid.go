@@ -0,0 +1,75 @@
+package game
+
+import "math"
+
+// GridCell is the side of a broad-phase grid cell, about a hull and a half
+const GridCell = 64
+
+// hullReach is how far any part of a hull can be from the tank's center
+var hullReach = math.Hypot(HullLength/2, HullWidth/2)
+
+// grid is a uniform broad-phase grid over the arena. Tanks are filed under
+// the cell of their center, so a query grows its area by how far a hull
+// reaches instead of filing tanks in every cell they overlap. Cells are
+// reused between frames so building it doesn't allocate once warmed up.
+type grid struct {
+	cols, rows int
+	cells      [][]*Player
+}
+
+// build files every tank still in the match
+func (gr *grid) build(players map[string]*Player) {
+	if gr.cells == nil {
+		gr.cols = ScreenWidth/GridCell + 1
+		gr.rows = ScreenHeight/GridCell + 1
+		gr.cells = make([][]*Player, gr.cols*gr.rows)
+	}
+	for i := range gr.cells {
+		gr.cells[i] = gr.cells[i][:0]
+	}
+	for _, p := range players {
+		if p.eliminated {
+			continue
+		}
+		col, row := gr.cell(p.X, p.Y)
+		i := row*gr.cols + col
+		gr.cells[i] = append(gr.cells[i], p)
+	}
+}
+
+// cell returns the cell a point falls in, points outside the arena count
+// as the nearest edge cell
+func (gr *grid) cell(x, y float64) (int, int) {
+	col := int(clamp(math.Floor(x/GridCell), 0, float64(gr.cols-1)))
+	row := int(clamp(math.Floor(y/GridCell), 0, float64(gr.rows-1)))
+	return col, row
+}
+
+// near appends to buf the tanks whose center lies in any cell touching the
+// rectangle from (minX, minY) to (maxX, maxY) grown by pad, and returns it
+func (gr *grid) near(minX, minY, maxX, maxY, pad float64, buf []*Player) []*Player {
+	c0, r0 := gr.cell(minX-pad, minY-pad)
+	c1, r1 := gr.cell(maxX+pad, maxY+pad)
+	for row := r0; row <= r1; row++ {
+		for col := c0; col <= c1; col++ {
+			buf = append(buf, gr.cells[row*gr.cols+col]...)
+		}
+	}
+	return buf
+}
+
+// compactBullets drops bullets that stopped before this step, keeping the
+// order and reusing the slice. Bullets stopped during a step stay one more
+// frame so they are still seen where they hit.
+func (g *Game) compactBullets() {
+	kept := g.Bullets[:0]
+	for _, b := range g.Bullets {
+		if b.Active {
+			kept = append(kept, b)
+		}
+	}
+	for i := len(kept); i < len(g.Bullets); i++ {
+		g.Bullets[i] = Bullet{} // Let go of the old bullets' strings
+	}
+	g.Bullets = kept
+}
@@ -0,0 +1,127 @@
+package game_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Bullet Compaction**
+func TestStoppedBulletsAreDropped(t *testing.T) {
+	gameInstance := &game.Game{Players: make(map[string]*game.Player)}
+	gameInstance.Players["victim"] = &game.Player{ID: "victim", X: 100, Y: 100, Health: game.MaxHealth}
+	for i := 0; i < 10; i++ {
+		gameInstance.AddBulletFromPeer(game.BulletMessage{ID: uint32(i), OwnerID: "shooter", X: 100, Y: float64(90 + 40*i), VX: 1})
+	}
+
+	gameInstance.UpdateBullets()
+	if len(gameInstance.Bullets) != 10 || gameInstance.Bullets[0].Active {
+		t.Fatalf("Expected the hit bullet to stay for a frame, got %d bullets", len(gameInstance.Bullets))
+	}
+
+	gameInstance.UpdateBullets()
+	if len(gameInstance.Bullets) != 9 || gameInstance.Bullets[0].ID != 1 {
+		t.Errorf("Expected the stopped bullet dropped and the others in order, got %d bullets", len(gameInstance.Bullets))
+	}
+}
+
+// ** Test Grid Finds Every Hit**
+func TestGridFindsTanksAcrossCells(t *testing.T) {
+	gameInstance := &game.Game{Players: make(map[string]*game.Player), Authoritative: true}
+
+	// Tanks straddling cell edges, hit by bullets from the neighboring cell
+	for i, x := range []float64{game.GridCell - 1, 2 * game.GridCell, 5*game.GridCell + 1} {
+		id := fmt.Sprintf("tank-%d", i)
+		gameInstance.Players[id] = &game.Player{ID: id, X: x, Y: 300, Angle: math.Pi / 2, Health: game.MaxHealth}
+		gameInstance.AddBulletFromPeer(game.BulletMessage{ID: uint32(i), OwnerID: "shooter", X: x + game.HullWidth/2 + 3, Y: 300 + game.HullLength/2 - 2, VX: -4})
+	}
+	gameInstance.UpdateBullets()
+
+	for id, p := range gameInstance.Players {
+		if p.Health == game.MaxHealth {
+			t.Errorf("Expected %s to be hit", id)
+		}
+	}
+}
+
+// benchmarkBullets runs bullet updates with the given numbers of bullets
+// and tanks spread over the arena. Nobody owns the tanks, so hits never
+// end the match.
+func benchmarkBullets(b *testing.B, bullets, tanks int) {
+	gameInstance := &game.Game{Players: make(map[string]*game.Player)}
+	for i := 0; i < tanks; i++ {
+		id := fmt.Sprintf("tank-%d", i)
+		gameInstance.Players[id] = &game.Player{
+			ID:     id,
+			X:      float64(40 + (i*97)%(game.ScreenWidth-80)),
+			Y:      float64(40 + (i*61)%(game.ScreenHeight-80)),
+			Angle:  float64(i),
+			Health: game.MaxHealth,
+		}
+	}
+	for i := 0; i < bullets; i++ {
+		angle := float64(i) * 2.39996 // Golden angle, spreads them evenly
+		gameInstance.AddBulletFromPeer(game.BulletMessage{
+			ID:      uint32(i),
+			OwnerID: "shooter",
+			X:       float64((i * 37) % game.ScreenWidth),
+			Y:       float64((i * 53) % game.ScreenHeight),
+			VX:      game.BulletSpeed * math.Cos(angle),
+			VY:      game.BulletSpeed * math.Sin(angle),
+		})
+	}
+	start := append([]game.Bullet(nil), gameInstance.Bullets...)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%60 == 0 {
+			gameInstance.Bullets = append(gameInstance.Bullets[:0], start...)
+		}
+		gameInstance.UpdateBullets()
+	}
+}
+
+func BenchmarkUpdateBullets(b *testing.B) {
+	for _, c := range []struct{ bullets, tanks int }{{100, 8}, {300, 24}, {500, 48}, {1000, 48}} {
+		b.Run(fmt.Sprintf("%d_bullets_%d_tanks", c.bullets, c.tanks), func(b *testing.B) {
+			benchmarkBullets(b, c.bullets, c.tanks)
+		})
+	}
+}
+
+// benchmarkCollisions runs authority ticks where every one of the tanks
+// drives, so each move is resolved against the tanks around it
+func benchmarkCollisions(b *testing.B, tanks int) {
+	server := &game.Game{Players: make(map[string]*game.Player), Authoritative: true}
+	ids := make([]string, tanks)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("tank-%d", i)
+		server.Players[ids[i]] = &game.Player{
+			ID:     ids[i],
+			X:      float64(40 + (i*97)%(game.ScreenWidth-80)),
+			Y:      float64(40 + (i*61)%(game.ScreenHeight-80)),
+			Health: game.MaxHealth,
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		angle := float64(i) * 0.05
+		for _, id := range ids {
+			server.ApplyRemoteInput(game.InputMessage{Type: "input", ID: id, PlayerInput: game.PlayerInput{
+				Seq: uint32(i + 1), MoveX: math.Cos(angle), MoveY: math.Sin(angle),
+			}})
+		}
+		server.Tick()
+	}
+}
+
+func BenchmarkTankCollisions(b *testing.B) {
+	for _, tanks := range []int{24, 48, 200} {
+		b.Run(fmt.Sprintf("%d_tanks", tanks), func(b *testing.B) {
+			benchmarkCollisions(b, tanks)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// HitMessage struct (sent by the victim's client once it confirms a hit)
+//
+// Hit resolution uses victim authority: every peer simulates bullets and
+// hides them on contact, but only the client that owns the victim applies
+// damage and broadcasts the resulting health, so all peers agree on health
+// and eliminations. With a dedicated server the server is that authority.
+type HitMessage struct {
+	Type       string `json:"type"`      // "hit"
+	VictimID   string `json:"victim_id"` // Player who was hit
+	ShooterID  string `json:"shooter_id"`
+	BulletID   uint32 `json:"bullet_id"`
+	Health     int    `json:"health"` // Victim's health after the hit
+	Shield     int    `json:"shield,omitempty"` // Victim's shield after the hit
+	Eliminated bool   `json:"eliminated"`
+	DamageReport      // Where the hit landed and how much it took
+}
+
+// UpdateBullets moves all bullets and resolves hits against players. Only
+// tanks the grid finds near a bullet's step are tested.
+func (g *Game) UpdateBullets() {
+	g.compactBullets()
+	g.grid.build(g.Players)
+
+	for i := range g.Bullets {
+		b := &g.Bullets[i]
+		if !b.Active {
+			continue
+		}
+		w := WeaponByID(b.Weapon)
+
+		b.X += b.vx
+		b.Y += b.vy
+		b.traveled += math.Hypot(b.vx, b.vy)
+
+		// Bullet out of bounds check, some weapons ricochet
+		if b.X < 0 || b.X > ScreenWidth || b.Y < 0 || b.Y > ScreenHeight {
+			if b.bounces < w.Bounces {
+				g.bounce(b)
+			} else {
+				b.Active = false
+				g.splash(*b, "")
+				continue
+			}
+		}
+
+		// Out of range: it fizzles (or explodes) where it is
+		if w.Range > 0 && b.traveled > w.Range {
+			b.Active = false
+			g.splash(*b, "")
+			continue
+		}
+
+		// Bullet collision with other players: the first hull along its step
+		// takes it, the lowest ID on a tie so every peer agrees
+		var victim *Player
+		hitAt := math.Inf(1)
+		x0, y0 := b.X-b.vx, b.Y-b.vy
+		g.nearby = g.grid.near(math.Min(x0, b.X), math.Min(y0, b.Y), math.Max(x0, b.X), math.Max(y0, b.Y), hullReach+BulletRadius, g.nearby[:0])
+		for _, target := range g.nearby {
+			pid := target.ID
+			if pid == b.OwnerID || target.eliminated || g.harmless(b.OwnerID, pid) {
+				continue
+			}
+			if at, ok := bulletHit(*b, target); ok && (at < hitAt || at == hitAt && pid < victim.ID) {
+				victim, hitAt = target, at
+			}
+		}
+		if victim == nil {
+			continue
+		}
+		b.Active = false
+		b.X -= b.vx * (1 - hitAt) // Back to where it struck the hull
+		b.Y -= b.vy * (1 - hitAt)
+
+		// Only the referee, or else the victim's own client, decides the outcome
+		if g.Authoritative || (g.ownsPlayer(victim.ID) && !g.Client) {
+			g.confirmHit(victim, *b, w.Damage, true)
+		}
+		g.splash(*b, victim.ID)
+	}
+}
+
+// confirmHit applies damage to a player we own and tells every peer.
+// Direct hits go through the hull armor, splash damage doesn't.
+func (g *Game) confirmHit(victim *Player, b Bullet, damage int, direct bool) {
+	report := applyDamage(victim, b, damage, direct)
+	g.indicateHit(victim.ID, report)
+	fmt.Println("Player", victim.ID, "hit", report.Side, "for", report.Damage, "! New health:", victim.Health)
+
+	msg := HitMessage{
+		Type:       "hit",
+		VictimID:   victim.ID,
+		ShooterID:  b.OwnerID,
+		BulletID:   b.ID,
+		Health:     victim.Health,
+		Shield:     victim.Shield,
+		Eliminated:   victim.Health <= 0,
+		DamageReport: report,
+	}
+	if msg.Eliminated {
+		g.eliminate(victim, b.OwnerID)
+	}
+
+	g.Recorder.Record(RecordOut, msg)
+	if g.SendUpdate != nil {
+		g.SendUpdate(msg)
+	}
+}
+
+// ApplyHit applies a hit confirmed by the victim's client
+func (g *Game) ApplyHit(msg HitMessage) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	// The bullet may still be flying here if our simulation missed the contact
+	for i := range g.Bullets {
+		if g.Bullets[i].OwnerID == msg.ShooterID && g.Bullets[i].ID == msg.BulletID {
+			g.Bullets[i].Active = false
+		}
+	}
+
+	victim, exists := g.Players[msg.VictimID]
+	if !exists {
+		return
+	}
+	victim.Health = msg.Health
+	victim.Shield = msg.Shield
+	victim.sinceHit = 0
+	g.indicateHit(victim.ID, msg.DamageReport)
+	if msg.Eliminated {
+		g.eliminate(victim, msg.ShooterID)
+	}
+}
+
+// eliminate marks a player as eliminated and credits the shooter unless
+// they are teammates. In the respawning modes it comes back after
+// RespawnDelay, otherwise it is removed after RemoveDelay.
+func (g *Game) eliminate(p *Player, shooterID string) {
+	if p.eliminated {
+		return
+	}
+	fmt.Println("Player", p.ID, "eliminated!")
+	p.eliminated = true
+	if shooter, exists := g.Players[shooterID]; exists && shooterID != p.ID && !g.Teammates(shooterID, p.ID) {
+		shooter.Score++
+	}
+
+	if g.Match.respawning() {
+		p.respawn = RespawnDelay
+		return
+	}
+	g.scheduleRemoval(p.ID)
+}
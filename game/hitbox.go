@@ -0,0 +1,103 @@
+package game
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// HitboxKey toggles the hitbox overlay
+const HitboxKey = ebiten.KeyF3
+
+// BulletRadius is how close a bullet's center has to come to a hull to hit it
+const BulletRadius = BulletSize / 2.0
+
+// Sweep tests the segment from (x0, y0) to (x1, y1) against a box grown by
+// radius on every side. It returns how far along the segment, from 0 to 1,
+// it first enters the box; a segment that only grazes the box misses. The
+// grown box has square corners, a little generous to bullets near them.
+func Sweep(x0, y0, x1, y1, radius float64, box Box) (float64, bool) {
+	// Work in the box's frame, where it is axis-aligned around the origin
+	sin, cos := math.Sincos(box.Angle)
+	local := func(x, y float64) (float64, float64) {
+		dx, dy := x-box.X, y-box.Y
+		return dx*cos + dy*sin, -dx*sin + dy*cos
+	}
+	ax, ay := local(x0, y0)
+	bx, by := local(x1, y1)
+
+	enter, exit := 0.0, 1.0
+	for _, slab := range [2][3]float64{{ax, bx - ax, box.HalfW + radius}, {ay, by - ay, box.HalfH + radius}} {
+		start, delta, half := slab[0], slab[1], slab[2]
+		if delta == 0 {
+			if math.Abs(start) >= half {
+				return 0, false // Parallel to the slab and outside it
+			}
+			continue
+		}
+		t0, t1 := (-half-start)/delta, (half-start)/delta
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		enter = math.Max(enter, t0)
+		exit = math.Min(exit, t1)
+		if enter >= exit {
+			return 0, false
+		}
+	}
+	return enter, true
+}
+
+// bulletHit sweeps a bullet over its last step against a player's hull
+// and returns how far along the step it hit
+func bulletHit(b Bullet, p *Player) (float64, bool) {
+	return Sweep(b.X-b.vx, b.Y-b.vy, b.X, b.Y, BulletRadius, HullBox(p))
+}
+
+// Corners returns the box's corners in drawing order
+func (b Box) Corners() [4][2]float64 {
+	edges := b.axes()
+	var corners [4][2]float64
+	for i, sign := range [4][2]float64{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}} {
+		corners[i] = [2]float64{
+			b.X + sign[0]*b.HalfW*edges[0][0] + sign[1]*b.HalfH*edges[1][0],
+			b.Y + sign[0]*b.HalfW*edges[0][1] + sign[1]*b.HalfH*edges[1][1],
+		}
+	}
+	return corners
+}
+
+// updateHitboxKey toggles the hitbox overlay
+func (g *Game) updateHitboxKey() {
+	if inpututil.IsKeyJustPressed(HitboxKey) {
+		g.ShowHitboxes = !g.ShowHitboxes
+	}
+}
+
+// drawHitboxes outlines what hits are tested against: every hull where the
+// simulation has it and every bullet's last step
+func (g *Game) drawHitboxes(screen *ebiten.Image) {
+	hull := color.RGBA{0, 255, 0, 255}
+	for _, p := range g.Players {
+		if p.eliminated {
+			continue
+		}
+		corners := HullBox(p).Corners()
+		for i, a := range corners {
+			b := corners[(i+1)%len(corners)]
+			vector.StrokeLine(screen, float32(a[0]), float32(a[1]), float32(b[0]), float32(b[1]), 1, hull, true)
+		}
+	}
+
+	sweep := color.RGBA{255, 255, 0, 255}
+	for _, b := range g.Bullets {
+		if !b.Active {
+			continue
+		}
+		vector.StrokeLine(screen, float32(b.X-b.vx), float32(b.Y-b.vy), float32(b.X), float32(b.Y), 1, sweep, true)
+		vector.StrokeCircle(screen, float32(b.X), float32(b.Y), BulletRadius, 1, sweep, true)
+	}
+}
@@ -0,0 +1,68 @@
+package game_test
+
+import (
+	"math"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Rotated Hitbox**
+func TestHitboxFollowsTheHull(t *testing.T) {
+	gameInstance := &game.Game{Players: make(map[string]*game.Player)}
+	tank := &game.Player{ID: "tank", X: 200, Y: 200, Angle: math.Pi / 2, Health: game.MaxHealth}
+
+	// Hull turned to point down: long along Y, narrow along X
+	inside := game.Bullet{X: 200, Y: 200 + game.HullLength/2 - 2, Active: true}
+	outside := game.Bullet{X: 200 + game.HullWidth/2 + 4, Y: 200, Active: true}
+	if !game.CheckCollision(inside, tank, gameInstance) {
+		t.Errorf("Expected a hit near the front of the turned hull")
+	}
+	if game.CheckCollision(outside, tank, gameInstance) {
+		t.Errorf("Expected a miss beside the turned hull")
+	}
+
+	// Centered on X, Y like the sprite, not anchored at its corner
+	if !game.CheckCollision(game.Bullet{X: 190, Y: 190, Active: true}, tank, gameInstance) {
+		t.Errorf("Expected a hit up and left of the tank's center")
+	}
+}
+
+// ** Test Swept Bullets**
+func TestFastBulletsCannotTunnel(t *testing.T) {
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+	}
+	gameInstance.Players["near"] = &game.Player{ID: "near", X: 200, Y: 100, Angle: math.Pi, Health: game.MaxHealth}
+	gameInstance.Players["far"] = &game.Player{ID: "far", X: 260, Y: 100, Angle: math.Pi, Health: game.MaxHealth}
+
+	// One step jumps from before the near tank to past the far one
+	gameInstance.AddBulletFromPeer(game.BulletMessage{ID: 1, OwnerID: "shooter", X: 150, Y: 100, VX: 150})
+	gameInstance.UpdateBullets()
+
+	if gameInstance.Bullets[0].Active {
+		t.Fatalf("Expected the bullet to hit something on its way")
+	}
+	if gameInstance.Players["near"].Health != game.MaxHealth-game.DamageAmount {
+		t.Errorf("Expected the first tank in the way to take the hit, health %d", gameInstance.Players["near"].Health)
+	}
+	if gameInstance.Players["far"].Health != game.MaxHealth {
+		t.Errorf("Expected the tank behind it to be unharmed, health %d", gameInstance.Players["far"].Health)
+	}
+	if x := gameInstance.Bullets[0].X; x > 200-game.HullLength/2 || x < 150 {
+		t.Errorf("Expected the bullet to stop at the front of the hull, got x %f", x)
+	}
+}
+
+// ** Test Sweep Grazing**
+func TestSweepGrazingMisses(t *testing.T) {
+	box := game.Box{X: 0, Y: 0, HalfW: 10, HalfH: 5}
+	if _, hit := game.Sweep(-20, 5, 20, 5, 0, box); hit {
+		t.Errorf("Expected a segment along the edge to miss")
+	}
+	at, hit := game.Sweep(-20, 0, 20, 0, 0, box)
+	if !hit || math.Abs(at-0.25) > 1e-9 {
+		t.Errorf("Expected to enter a quarter of the way along, got %f %v", at, hit)
+	}
+}
@@ -0,0 +1,144 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Host-authoritative mesh settings
+const (
+	HostTimeout       = 2 * time.Second // Silence after which a peer counts as gone
+	HeartbeatInterval = 30              // Frames between heartbeats (~0.5s)
+)
+
+// HeartbeatMessage struct (sent by every peer in host mode so the others
+// know who is still alive and can take part in host election)
+type HeartbeatMessage struct {
+	Type string `json:"type"` // "heartbeat"
+	ID   string `json:"id"`
+}
+
+// senderTypes are the messages whose "id" is the peer that sent them, a
+// bullet's "id" is its number
+var senderTypes = map[string]bool{"join": true, "input": true, "move": true, "heartbeat": true, "spectate": true}
+
+// now returns the current time from the game's clock
+func (g *Game) now() time.Time {
+	if g.Clock != nil {
+		return g.Clock()
+	}
+	return time.Now()
+}
+
+// heard records that a peer is alive
+func (g *Game) heard(id string) {
+	if id == "" {
+		return
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.lastHeard == nil {
+		g.lastHeard = make(map[string]time.Time)
+	}
+	g.lastHeard[id] = g.now()
+}
+
+// alive reports whether a peer was heard from recently, must hold mutex
+func (g *Game) alive(id string) bool {
+	if id == g.LocalPlayerID {
+		return true
+	}
+	last, known := g.lastHeard[id]
+	return known && g.now().Sub(last) < HostTimeout
+}
+
+// ElectHost picks the host: the lowest ID among the peers that are alive.
+// Every peer applies the same rule, so they agree once they have heard
+// from each other.
+func (g *Game) ElectHost() string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	host := g.LocalPlayerID
+	if g.Spectator {
+		host = "" // Spectators never referee
+	}
+	for id := range g.lastHeard {
+		if g.alive(id) && (host == "" || id < host) {
+			host = id
+		}
+	}
+	return host
+}
+
+// updateHost re-runs the election and switches between host and client
+func (g *Game) updateHost() {
+	g.frame++
+	if g.frame%HeartbeatInterval == 0 && g.SendUpdate != nil {
+		if g.Spectator {
+			g.SendUpdate(SpectateMessage{Type: "spectate", ID: g.LocalPlayerID})
+		} else {
+			g.SendUpdate(HeartbeatMessage{Type: "heartbeat", ID: g.LocalPlayerID})
+		}
+	}
+
+	host := g.ElectHost()
+	if host != g.HostID {
+		fmt.Println("Host is now:", host)
+		g.HostID = host
+		g.Authoritative = host == g.LocalPlayerID
+		g.Client = !g.Authoritative
+
+		// The new host continues from the latest snapshot it applied, so
+		// its world is already the match state
+		if g.Authoritative {
+			g.pendingInputs = nil
+		} else {
+			g.tick = 0 // Accept the new host's numbering
+		}
+		g.joinSent = false
+	}
+
+	// Make sure the host knows about our tank
+	if g.Client && (!g.joinSent || g.missingFromSnapshot) && g.frame%HeartbeatInterval == 0 {
+		if player, exists := g.Players[g.LocalPlayerID]; exists && !player.eliminated && g.SendUpdate != nil {
+			g.SendUpdate(JoinMessage{Type: "join", ID: g.LocalPlayerID, Team: g.Team})
+			g.joinSent = true
+		}
+	}
+}
+
+// hostStep advances the match on the host and shares the result
+func (g *Game) hostStep() {
+	// Players whose peer went quiet have left the match
+	var clients []string
+	g.mutex.Lock()
+	for id := range g.Players {
+		if last, known := g.lastHeard[id]; known && id != g.LocalPlayerID && g.now().Sub(last) > HostTimeout {
+			fmt.Println("Player timed out:", id)
+			delete(g.Players, id)
+		} else if id != g.LocalPlayerID {
+			clients = append(clients, id)
+		}
+	}
+	clients = append(clients, g.watchers()...)
+	g.mutex.Unlock()
+
+	snap := g.Tick()
+	snap.Host = g.LocalPlayerID
+	g.Recorder.Record(RecordOut, snap)
+	g.sendSnapshot(snap, clients)
+}
+
+// acceptSnapshot filters snapshots in host mode to the elected host
+func (g *Game) acceptSnapshot(data []byte) bool {
+	if !g.HostMode {
+		return true
+	}
+	var envelope struct {
+		Host string `json:"host"`
+	}
+	return json.Unmarshal(data, &envelope) == nil && envelope.Host == g.HostID
+}
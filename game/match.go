@@ -0,0 +1,65 @@
+package game
+
+// Movement models a match can be played with
+const (
+	ArcadeMovement = "arcade" // Instant eight-way movement at PlayerSpeed
+	TankMovement   = "tank"   // Hull steers, tracks accelerate and coast, see physics.go
+)
+
+// Game modes
+const (
+	EliminationMode   = "elimination" // Eliminated tanks are out until the match restarts
+	DeathmatchMode    = "deathmatch"  // Eliminated tanks respawn, most eliminations wins
+	KingOfTheHillMode = "koth"        // Teams respawn and score by holding the hill alone
+)
+
+// MatchConfig holds the rules every peer in a match has to agree on. A
+// server or elected host sends its config in every snapshot; in the plain
+// mesh every peer must be started with the same settings.
+type MatchConfig struct {
+	Movement     string `json:"movement,omitempty"`      // ArcadeMovement ("" too) or TankMovement
+	Mode         string `json:"mode,omitempty"`          // EliminationMode ("" too), DeathmatchMode or KingOfTheHillMode
+	ScoreLimit   int    `json:"score_limit,omitempty"`   // Respawning modes end at this score, 0 for none
+	TimeLimit    int    `json:"time_limit,omitempty"`    // Respawning modes end after this many seconds, 0 for none
+	Teams        int    `json:"teams,omitempty"`         // Number of teams (up to MaxTeams), 0 for every tank on its own
+	FriendlyFire bool   `json:"friendly_fire,omitempty"` // Bullets hurt teammates
+	ShieldRegen  bool   `json:"shield_regen,omitempty"`  // Tanks recharge a shield of RegenShield out of combat
+	Pickups      bool   `json:"pickups,omitempty"`       // Spawn health packs, shields, power-ups and weapon crates
+	Seed         int64  `json:"seed,omitempty"`          // Decides which pickups appear, see pickupFor
+}
+
+// ValidMode reports whether name is a known game mode
+func ValidMode(name string) bool {
+	return name == "" || name == EliminationMode || name == DeathmatchMode || name == KingOfTheHillMode
+}
+
+// ValidTeams reports whether a match can be played with n teams
+func ValidTeams(n int) bool {
+	return n >= 0 && n <= MaxTeams
+}
+
+// TeamsFor returns how many teams a mode is played with: king of the hill
+// needs at least two, the other modes take what was asked for
+func TeamsFor(mode string, teams int) int {
+	if mode == KingOfTheHillMode && teams < 2 {
+		return 2
+	}
+	return teams
+}
+
+// respawning reports whether eliminated tanks come back in this mode
+func (m MatchConfig) respawning() bool {
+	return m.Mode == DeathmatchMode || m.Mode == KingOfTheHillMode
+}
+
+// NeedsReferee reports whether the match has to be scored by an authority
+// (a server or elected host): mesh peers each see the hill a little
+// differently, so they would never agree on its points
+func (m MatchConfig) NeedsReferee() bool {
+	return m.Mode == KingOfTheHillMode
+}
+
+// ValidMovement reports whether name is a known movement model
+func ValidMovement(name string) bool {
+	return name == "" || name == ArcadeMovement || name == TankMovement
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// MatchFlags are the command-line flags for a match's rules. The player,
+// cmd/bot and cmd/server all register the same ones, so every peer of a
+// mesh can be started with the same rules.
+type MatchFlags struct {
+	Movement     string
+	Mode         string
+	ScoreLimit   int
+	TimeLimit    time.Duration
+	Teams        int
+	FriendlyFire bool
+	ShieldRegen  bool
+	Pickups      bool
+	Seed         int64
+}
+
+// RegisterMatchFlags registers the match flags on fs, seed is the default
+// for -seed (0 to pick one from the clock)
+func RegisterMatchFlags(fs *flag.FlagSet, seed int64) *MatchFlags {
+	f := &MatchFlags{}
+	fs.StringVar(&f.Movement, "movement", ArcadeMovement, "Movement model: arcade, or tank (W/S throttle, A/D steer); a server or host decides for its match")
+	fs.StringVar(&f.Mode, "mode", EliminationMode, "Game mode: elimination, deathmatch (respawns, most eliminations wins) or koth (teams hold the hill, scored by a server or host)")
+	fs.IntVar(&f.ScoreLimit, "score-limit", 10, "Eliminations (or hill points) to win in deathmatch and koth, 0 for no limit")
+	fs.DurationVar(&f.TimeLimit, "time-limit", 5*time.Minute, "Deathmatch and koth length, 0 for no limit")
+	fs.IntVar(&f.Teams, "teams", 0, "Number of teams, up to 4 (0 for free-for-all, koth needs 2)")
+	fs.BoolVar(&f.FriendlyFire, "friendly-fire", false, "Bullets hurt teammates")
+	fs.BoolVar(&f.ShieldRegen, "shield-regen", false, "Tanks recharge a small shield after a few seconds out of combat")
+	fs.BoolVar(&f.Pickups, "pickups", false, "Spawn health packs, shields, power-ups and weapon crates")
+	seedUsage := "Decides which pickups appear, every mesh peer must use the same one"
+	if seed == 0 {
+		seedUsage = "Decides which pickups appear, 0 picks one from the clock"
+	}
+	fs.Int64Var(&f.Seed, "seed", seed, seedUsage)
+	return f
+}
+
+// Config checks the flags and returns the match rules they give
+func (f *MatchFlags) Config() (MatchConfig, error) {
+	if !ValidMovement(f.Movement) {
+		return MatchConfig{}, fmt.Errorf("unknown movement model %q", f.Movement)
+	}
+	if !ValidMode(f.Mode) {
+		return MatchConfig{}, fmt.Errorf("unknown game mode %q", f.Mode)
+	}
+	if !ValidTeams(f.Teams) {
+		return MatchConfig{}, fmt.Errorf("teams must be between 0 and %d", MaxTeams)
+	}
+	return MatchConfig{
+		Movement:     f.Movement,
+		Mode:         f.Mode,
+		ScoreLimit:   f.ScoreLimit,
+		TimeLimit:    int(f.TimeLimit.Seconds()),
+		Teams:        TeamsFor(f.Mode, f.Teams),
+		FriendlyFire: f.FriendlyFire,
+		ShieldRegen:  f.ShieldRegen,
+		Pickups:      f.Pickups,
+		Seed:         f.Seed,
+	}, nil
+}
@@ -0,0 +1,34 @@
+package game_test
+
+import (
+	"flag"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Match Flags**
+func TestMatchFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := game.RegisterMatchFlags(fs, 1)
+	if err := fs.Parse([]string{"-mode", "koth", "-friendly-fire"}); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+
+	match, err := flags.Config()
+	if err != nil {
+		t.Fatalf("Expected valid settings: %v", err)
+	}
+	// The defaults every peer shares, and what koth needs
+	if match.ScoreLimit != 10 || match.TimeLimit != 300 || match.Seed != 1 {
+		t.Errorf("Expected the default limits and seed, got %+v", match)
+	}
+	if match.Teams != 2 || !match.FriendlyFire {
+		t.Errorf("Expected two teams with friendly fire, got %+v", match)
+	}
+
+	flags.Mode = "capture"
+	if _, err := flags.Config(); err == nil {
+		t.Errorf("Expected an unknown mode to be rejected")
+	}
+}
@@ -0,0 +1,53 @@
+package game
+
+import "math"
+
+// Tank movement tuning, per frame at 60 FPS
+const (
+	TankAcceleration = 0.12 // Speed gained per frame at full throttle
+	TankFriction     = 0.06 // Speed lost per frame with the throttle released
+	TankMaxSpeed     = 3.0  // Top speed driving forward
+	TankReverseSpeed = 1.5  // Top speed backing up
+	TankTurnRate     = 0.05 // Hull rotation per frame at full steer (radians)
+)
+
+// driveTank moves a player with the tank model: MoveX steers the hull,
+// MoveY is the throttle (negative, like W or stick up, drives forward)
+// and speed builds up and bleeds off instead of changing instantly
+func driveTank(player *Player, input PlayerInput) {
+	player.Angle = math.Remainder(player.Angle+input.MoveX*TankTurnRate, 2*math.Pi)
+
+	throttle := -input.MoveY * speedFactor(player)
+	switch {
+	case throttle > 0:
+		player.Speed = approach(player.Speed, throttle*TankMaxSpeed, TankAcceleration)
+	case throttle < 0:
+		player.Speed = approach(player.Speed, throttle*TankReverseSpeed, TankAcceleration)
+	default:
+		player.Speed = approach(player.Speed, 0, TankFriction)
+	}
+
+	player.X += math.Cos(player.Angle) * player.Speed
+	player.Y += math.Sin(player.Angle) * player.Speed
+}
+
+// approach moves v towards target by at most step
+func approach(v, target, step float64) float64 {
+	if v < target {
+		return math.Min(v+step, target)
+	}
+	return math.Max(v-step, target)
+}
+
+// Steer converts a direction a computer player wants to drive in (arena
+// coordinates) into input for the match's movement model
+func (g *Game) Steer(player *Player, dirX, dirY float64) (float64, float64) {
+	if g.Match.Movement != TankMovement || (dirX == 0 && dirY == 0) {
+		return dirX, dirY
+	}
+
+	// Turn towards the direction, only driving once roughly facing it
+	turn := math.Remainder(math.Atan2(dirY, dirX)-player.Angle, 2*math.Pi)
+	throttle := math.Max(0, math.Cos(turn)) * math.Min(1, math.Hypot(dirX, dirY))
+	return clamp(turn/(TankTurnRate*4), -1, 1), -throttle
+}
@@ -0,0 +1,364 @@
+package game
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Pickup settings, in frames at 60 FPS
+const (
+	PickupRadius  = 16  // Distance from a tank's center that collects a pickup
+	PickupRespawn = 600 // Frames before a spawn point offers a new pickup
+	ClaimRetry    = 30  // Frames before a mesh peer claims a pickup again if no grant came
+	HealthPack    = 25  // Health restored by a health pack
+	ShieldPoints  = 50  // Damage a shield absorbs before health is lost
+	BoostFrames   = 300 // Duration of a speed boost
+	BoostFactor   = 1.5 // Movement speed multiplier while boosted
+	RapidFrames   = 300 // Duration of rapid fire, which halves weapon cooldowns
+)
+
+// Pickup kinds
+const (
+	HealthPickup = "health"
+	ShieldPickup = "shield"
+	SpeedPickup  = "speed"
+	RapidPickup  = "rapid"
+	WeaponPickup = "weapon" // Crate holding a weapon other than the blaster
+)
+
+var pickupKinds = []string{HealthPickup, ShieldPickup, SpeedPickup, RapidPickup, WeaponPickup}
+
+// PickupSpawns are the points in the arena where pickups appear
+var PickupSpawns = [][2]float64{
+	{ScreenWidth / 2, ScreenHeight / 2},
+	{120, 120},
+	{ScreenWidth - 120, 120},
+	{120, ScreenHeight - 120},
+	{ScreenWidth - 120, ScreenHeight - 120},
+	{ScreenWidth / 2, 80},
+	{ScreenWidth / 2, ScreenHeight - 80},
+}
+
+// PickupState describes an available pickup in a snapshot
+type PickupState struct {
+	ID     int    `json:"id"`  // Index into PickupSpawns
+	Gen    int    `json:"gen"` // Pickups collected from this spawn point so far
+	Kind   string `json:"kind"`
+	Weapon string `json:"weapon,omitempty"` // Weapon crates only
+}
+
+// PickupMessage struct (sent by a mesh peer when one of its tanks touches
+// a pickup). Only the arbiter, the peer owning the lowest player ID, hands
+// pickups out: it grants each one to the first claim it gets. Nobody
+// collects before the grant, so exactly one tank gets it however late
+// the claims arrive.
+type PickupMessage struct {
+	Type     string `json:"type"` // "pickup"
+	ID       string `json:"id"`   // Claiming player
+	PickupID int    `json:"pickup_id"`
+	Gen      int    `json:"gen"`
+}
+
+// PickupGrantMessage struct (broadcast by the mesh arbiter when it hands a
+// pickup out, every peer then applies it)
+type PickupGrantMessage struct {
+	Type     string `json:"type"`      // "pickup_grant"
+	PlayerID string `json:"player_id"` // Player who gets it
+	PickupID int    `json:"pickup_id"`
+	Gen      int    `json:"gen"`
+}
+
+// pickup is the state of one spawn point
+type pickup struct {
+	PickupState
+	active  bool
+	respawn int // Frames until the next pickup appears
+	claimIn int // Frames until we may claim it again (mesh)
+}
+
+// pickupFor decides what spawn point id offers for its gen-th pickup. It
+// only depends on the match seed, so every peer agrees without talking.
+func pickupFor(seed int64, id, gen int) PickupState {
+	h := uint64(seed)*0x9E3779B97F4A7C15 ^ uint64(id)*0xBF58476D1CE4E5B9 ^ uint64(gen)*0x94D049BB133111EB
+	h ^= h >> 31
+	h *= 0x9E3779B97F4A7C15
+	h ^= h >> 29
+
+	state := PickupState{ID: id, Gen: gen, Kind: pickupKinds[h%uint64(len(pickupKinds))]}
+	if state.Kind == WeaponPickup {
+		state.Weapon = Weapons[1+int((h>>8)%uint64(len(Weapons)-1))].ID
+	}
+	return state
+}
+
+// initPickups places the first pickup on every spawn point, must hold mutex
+func (g *Game) initPickups() {
+	if g.pickups != nil || !g.Match.Pickups {
+		return
+	}
+	g.pickups = make([]pickup, len(PickupSpawns))
+	for id := range g.pickups {
+		g.pickups[id] = pickup{PickupState: pickupFor(g.Match.Seed, id, 0), active: true}
+	}
+}
+
+// updatePickups runs pickup timers and collection for one frame. An
+// authority gives pickups out directly, mesh peers claim them from the
+// arbiter.
+func (g *Game) updatePickups() {
+	if !g.Match.Pickups || g.Client {
+		return
+	}
+	g.initPickups()
+
+	ids := make([]string, 0, len(g.Players))
+	for id, player := range g.Players {
+		tickEffects(player)
+		ids = append(ids, id)
+	}
+	sort.Strings(ids) // Lowest ID wins ties, on every peer
+
+	for i := range g.pickups {
+		p := &g.pickups[i]
+		if !p.active {
+			if p.respawn--; p.respawn <= 0 {
+				*p = pickup{PickupState: pickupFor(g.Match.Seed, p.ID, p.Gen), active: true}
+			}
+			continue
+		}
+		if p.claimIn > 0 {
+			p.claimIn--
+		}
+
+		for _, id := range ids {
+			player := g.Players[id]
+			if player.eliminated || !touching(player, p.ID) {
+				continue
+			}
+			if g.Authoritative {
+				g.collect(p, player)
+				break
+			}
+			if g.ownsPlayer(id) && p.claimIn == 0 {
+				if g.claim(p, id) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// touching reports whether a player is over a spawn point
+func touching(player *Player, id int) bool {
+	spawn := PickupSpawns[id]
+	return math.Hypot(player.X-spawn[0], player.Y-spawn[1]) < PickupRadius+HullWidth/2
+}
+
+// arbiter returns the player whose peer hands out pickups in the mesh:
+// the lowest ID, so every peer agrees once they know each other. Must hold
+// mutex.
+func (g *Game) arbiter() string {
+	best := ""
+	for id := range g.Players {
+		if best == "" || id < best {
+			best = id
+		}
+	}
+	return best
+}
+
+// claim asks the arbiter for a pickup, or grants it when we are the
+// arbiter. It reports whether the pickup was handed out.
+func (g *Game) claim(p *pickup, playerID string) bool {
+	if g.ownsPlayer(g.arbiter()) {
+		g.grant(p, playerID)
+		return true
+	}
+
+	// Claim again later in case the arbiter missed it or left
+	p.claimIn = ClaimRetry
+	msg := PickupMessage{Type: "pickup", ID: playerID, PickupID: p.ID, Gen: p.Gen}
+	g.Recorder.Record(RecordOut, msg)
+	if g.SendUpdate != nil {
+		g.SendUpdate(msg)
+	}
+	return false
+}
+
+// grant hands a pickup to a player and tells every peer, arbiter only
+func (g *Game) grant(p *pickup, playerID string) {
+	msg := PickupGrantMessage{Type: "pickup_grant", PlayerID: playerID, PickupID: p.ID, Gen: p.Gen}
+	g.collect(p, g.Players[playerID])
+	g.Recorder.Record(RecordOut, msg)
+	if g.SendUpdate != nil {
+		g.SendUpdate(msg)
+	}
+}
+
+// ApplyPickupClaim grants another peer's claim if we are the arbiter and
+// the pickup is still there
+func (g *Game) ApplyPickupClaim(msg PickupMessage) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.initPickups()
+	if msg.PickupID < 0 || msg.PickupID >= len(g.pickups) || !g.ownsPlayer(g.arbiter()) {
+		return
+	}
+	p := &g.pickups[msg.PickupID]
+
+	// A peer ahead of us saw pickups we missed (we joined late), catch up
+	if msg.Gen > p.Gen {
+		*p = pickup{PickupState: pickupFor(g.Match.Seed, p.ID, msg.Gen), active: true}
+	}
+	if msg.Gen != p.Gen || !p.active {
+		return // Already handed out, or not back yet
+	}
+	if claimant, exists := g.Players[msg.ID]; exists && !claimant.eliminated {
+		g.grant(p, msg.ID)
+	}
+}
+
+// ApplyPickupGrant gives a pickup to the player the arbiter chose. It
+// applies even if our respawn timer hasn't brought the pickup back yet.
+func (g *Game) ApplyPickupGrant(msg PickupGrantMessage) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.initPickups()
+	if msg.PickupID < 0 || msg.PickupID >= len(g.pickups) {
+		return
+	}
+	p := &g.pickups[msg.PickupID]
+	if msg.Gen < p.Gen {
+		return // Already applied
+	}
+	*p = pickup{PickupState: pickupFor(g.Match.Seed, p.ID, msg.Gen), active: true}
+	g.collect(p, g.Players[msg.PlayerID])
+}
+
+// collect gives a pickup to a player, if it is still in the match, and
+// starts the respawn timer
+func (g *Game) collect(p *pickup, player *Player) {
+	// A player that left gets nothing, the pickup is gone all the same
+	if player != nil {
+		switch p.Kind {
+		case HealthPickup:
+			player.Health = int(math.Min(float64(player.Health+HealthPack), MaxHealth))
+		case ShieldPickup:
+			player.Shield = ShieldPoints
+		case SpeedPickup:
+			player.boost = BoostFrames
+		case RapidPickup:
+			player.rapid = RapidFrames
+		case WeaponPickup:
+			switchWeapon(player, p.Weapon)
+		}
+	}
+	*p = pickup{PickupState: PickupState{ID: p.ID, Gen: p.Gen + 1}, respawn: PickupRespawn}
+}
+
+// tickEffects counts down a player's power-ups
+func tickEffects(player *Player) {
+	if player.boost > 0 {
+		player.boost--
+	}
+	if player.rapid > 0 {
+		player.rapid--
+	}
+}
+
+// speedFactor returns how much faster than normal a player moves
+func speedFactor(player *Player) float64 {
+	if player.boost > 0 {
+		return BoostFactor
+	}
+	return 1
+}
+
+// pickupStates lists the available pickups for a snapshot
+func (g *Game) pickupStates() []PickupState {
+	var states []PickupState
+	for _, p := range g.pickups {
+		if p.active {
+			states = append(states, p.PickupState)
+		}
+	}
+	return states
+}
+
+// applyPickupStates shows exactly the pickups an authority says are
+// available, must hold mutex
+func (g *Game) applyPickupStates(states []PickupState) {
+	if len(states) == 0 && g.pickups == nil {
+		return
+	}
+	if g.pickups == nil {
+		g.pickups = make([]pickup, len(PickupSpawns))
+		for id := range g.pickups {
+			g.pickups[id].ID = id
+		}
+	}
+	available := make(map[int]PickupState, len(states))
+	for _, s := range states {
+		available[s.ID] = s
+	}
+	for i := range g.pickups {
+		p := &g.pickups[i]
+		if s, ok := available[i]; ok {
+			*p = pickup{PickupState: s, active: true}
+		} else if p.active {
+			// Collected: if we become the host, it respawns in due time
+			*p = pickup{PickupState: PickupState{ID: i, Gen: p.Gen + 1}, respawn: PickupRespawn}
+		}
+	}
+}
+
+// pickupColors tells pickup kinds apart
+var pickupColors = map[string]color.RGBA{
+	HealthPickup: {80, 220, 80, 255},
+	ShieldPickup: {80, 160, 255, 255},
+	SpeedPickup:  {255, 220, 60, 255},
+	RapidPickup:  {255, 90, 60, 255},
+	WeaponPickup: {200, 200, 200, 255},
+}
+
+// drawPickups renders available pickups pulsing on their spawn points
+func (g *Game) drawPickups(screen *ebiten.Image) {
+	pulse := float32(math.Sin(float64(time.Now().UnixMilli())/150)) * 2
+	for _, p := range g.pickups {
+		if !p.active {
+			continue
+		}
+		spawn := PickupSpawns[p.ID]
+		x, y := float32(spawn[0]), float32(spawn[1])
+		c := pickupColors[p.Kind]
+		vector.DrawFilledCircle(screen, x, y, PickupRadius/2+pulse, c, true)
+		vector.StrokeCircle(screen, x, y, PickupRadius+pulse, 1, c, true)
+
+		label := p.Kind
+		if p.Kind == WeaponPickup {
+			label = WeaponByID(p.Weapon).Name
+		}
+		ebitenutil.DebugPrintAt(screen, label, int(spawn[0])-len(label)*3, int(spawn[1])+PickupRadius+2)
+	}
+}
+
+// drawEffects renders a player's shield and power-ups around the tank
+func drawEffects(screen *ebiten.Image, player *Player, x, y float64) {
+	if player.Shield > 0 {
+		vector.StrokeCircle(screen, float32(x), float32(y), HullLength/2+4, 2, pickupColors[ShieldPickup], true)
+	}
+	if player.boost > 0 {
+		vector.StrokeCircle(screen, float32(x), float32(y), HullLength/2+8, 1, pickupColors[SpeedPickup], true)
+	}
+	if player.rapid > 0 {
+		vector.StrokeCircle(screen, float32(x), float32(y), HullLength/2+11, 1, pickupColors[RapidPickup], true)
+	}
+}
@@ -0,0 +1,165 @@
+package game_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"shooter/game"
+)
+
+// idle keeps a player still
+type idle struct{}
+
+func (idle) NextInput(g *game.Game, self *game.Player) game.PlayerInput {
+	return game.PlayerInput{Aim: self.TurretAngle}
+}
+
+// healthSeed finds a match seed whose first pickup on the center spawn point is a health pack
+func healthSeed(t *testing.T) int64 {
+	for seed := int64(1); seed < 1000; seed++ {
+		probe := &game.Game{Players: make(map[string]*game.Player), Authoritative: true, Match: game.MatchConfig{Pickups: true, Seed: seed}}
+		if snap := probe.Tick(); snap.Pickups[0].Kind == game.HealthPickup {
+			return seed
+		}
+	}
+	t.Fatalf("No seed gives a health pack")
+	return 0
+}
+
+// ** Test Authority Hands Out Pickups**
+func TestServerGivesPickupAndRespawnsIt(t *testing.T) {
+	server := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+		Match:         game.MatchConfig{Pickups: true, Seed: healthSeed(t)},
+	}
+	center := game.PickupSpawns[0]
+	server.Players["player1"] = &game.Player{ID: "player1", X: center[0], Y: center[1], Health: 50}
+
+	snap := server.Tick()
+	if health := server.Players["player1"].Health; health != 50+game.HealthPack {
+		t.Errorf("Expected the health pack to heal to %d, got %d", 50+game.HealthPack, health)
+	}
+	for _, p := range snap.Pickups {
+		if p.ID == 0 {
+			t.Fatalf("Expected the collected pickup to be gone from the snapshot")
+		}
+	}
+
+	// Step off and wait for the respawn
+	server.Players["player1"].X = 0
+	for i := 0; i < game.PickupRespawn; i++ {
+		snap = server.Tick()
+	}
+	if snap.Pickups[0].ID != 0 || snap.Pickups[0].Gen != 1 {
+		t.Errorf("Expected the spawn point to offer its next pickup, got %+v", snap.Pickups[0])
+	}
+}
+
+// meshPair is two mesh peers whose messages from peer-b reach peer-a
+// only after a delay, like a slow link
+type meshPair struct {
+	peers   map[string]*game.Game
+	inbox   map[string][]delivery
+	frame   int
+	delayed int
+}
+
+type delivery struct {
+	at   int
+	data []byte
+}
+
+func newMeshPair(t *testing.T, delay int) *meshPair {
+	m := &meshPair{peers: map[string]*game.Game{}, inbox: map[string][]delivery{}, delayed: delay}
+	match := game.MatchConfig{Pickups: true, Seed: healthSeed(t)}
+	for _, id := range []string{"peer-a", "peer-b"} {
+		from, to, lag := id, "peer-b", 0
+		if from == "peer-b" {
+			to, lag = "peer-a", delay
+		}
+		m.peers[id] = &game.Game{
+			Players:         make(map[string]*game.Player),
+			LocalPlayerID:   id,
+			LocalController: idle{},
+			Match:           match,
+			SendUpdate: func(msg interface{}) {
+				data, _ := json.Marshal(msg)
+				m.inbox[to] = append(m.inbox[to], delivery{at: m.frame + lag, data: data})
+			},
+		}
+	}
+	for _, g := range m.peers {
+		g.Players["peer-a"] = &game.Player{ID: "peer-a", X: 0, Y: 0, Health: 50}
+		g.Players["peer-b"] = &game.Player{ID: "peer-b", X: 0, Y: 0, Health: 50}
+	}
+	return m
+}
+
+// place puts a player's tank at the same spot on both peers
+func (m *meshPair) place(id string, x, y float64) {
+	for _, g := range m.peers {
+		g.Players[id].X, g.Players[id].Y = x, y
+	}
+}
+
+// run steps both peers and delivers what is due
+func (m *meshPair) run(frames int) {
+	for i := 0; i < frames; i++ {
+		for _, id := range []string{"peer-a", "peer-b"} {
+			m.peers[id].Update()
+		}
+		m.frame++
+		for id, g := range m.peers {
+			var later []delivery
+			for _, d := range m.inbox[id] {
+				if d.at <= m.frame {
+					g.HandleMessage(d.data)
+				} else {
+					later = append(later, d)
+				}
+			}
+			m.inbox[id] = later
+		}
+	}
+}
+
+// ** Test Mesh Pickup Claims**
+func TestPickupClaimsNeverCollectTwice(t *testing.T) {
+	// Both tanks reach the pickup on the same frame, peer-b's claim arrives
+	// long after the arbiter (peer-a, the lowest ID) gave it out
+	m := newMeshPair(t, 3*game.ClaimRetry)
+	center := game.PickupSpawns[0]
+	m.place("peer-a", center[0], center[1])
+	m.place("peer-b", center[0], center[1])
+	m.run(4 * game.ClaimRetry)
+
+	for name, g := range m.peers {
+		if health := g.Players["peer-a"].Health; health != 50+game.HealthPack {
+			t.Errorf("%s: expected the arbiter's tank to get the pickup, health %d", name, health)
+		}
+		if health := g.Players["peer-b"].Health; health != 50 {
+			t.Errorf("%s: expected the late claim to lose, health %d", name, health)
+		}
+	}
+}
+
+// ** Test Mesh Pickup Grant**
+func TestPickupGrantedAfterLatency(t *testing.T) {
+	m := newMeshPair(t, 3*game.ClaimRetry)
+	center := game.PickupSpawns[0]
+	m.place("peer-b", center[0], center[1])
+
+	// Nobody collects before the arbiter's grant
+	m.run(game.ClaimRetry)
+	if health := m.peers["peer-b"].Players["peer-b"].Health; health != 50 {
+		t.Fatalf("Expected peer-b to wait for the grant, health %d", health)
+	}
+
+	m.run(3 * game.ClaimRetry)
+	for name, g := range m.peers {
+		if health := g.Players["peer-b"].Health; health != 50+game.HealthPack {
+			t.Errorf("%s: expected peer-b to get the pickup once, health %d", name, health)
+		}
+	}
+}
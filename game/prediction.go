@@ -0,0 +1,185 @@
+package game
+
+import (
+	"math"
+)
+
+// Client-side prediction settings
+const (
+	MaxPendingInputs = 120  // Unacknowledged inputs kept for replay (~2s at 60 FPS)
+	CorrectionDecay  = 0.85 // Fraction of the visual correction left after each frame
+	SnapDistance     = 100  // Corrections larger than this are applied instantly
+)
+
+// PlayerInput is one frame of movement intent for a player
+type PlayerInput struct {
+	Seq   uint32  `json:"seq"`    // Increases by one for every input sent
+	MoveX float64 `json:"move_x"` // Horizontal movement (-1..1)
+	MoveY float64 `json:"move_y"` // Vertical movement (-1..1)
+	Fire  bool    `json:"fire"`
+	Aim   float64 `json:"aim"` // Turret angle in radians
+	Weapon string `json:"weapon,omitempty"` // Switch to this weapon, "" to keep the current one
+}
+
+// applyMovement aims the turret and moves a player by one frame of input
+// with the match's movement model, then resolves collisions
+func (g *Game) applyMovement(player *Player, input PlayerInput) {
+	player.TurretAngle = input.Aim
+
+	if g.Match.Movement == TankMovement {
+		if input.MoveX == 0 && input.MoveY == 0 && player.Speed == 0 {
+			return
+		}
+		driveTank(player, input)
+		g.collide(player)
+		return
+	}
+
+	vx := input.MoveX * PlayerSpeed * speedFactor(player)
+	vy := input.MoveY * PlayerSpeed * speedFactor(player)
+	if vx == 0 && vy == 0 {
+		return
+	}
+
+	player.Angle = math.Atan2(vy, vx)
+	player.X += vx
+	player.Y += vy
+	g.collide(player)
+}
+
+// ApplyLocalInput predicts the local player's movement immediately and
+// remembers the input until an authoritative state acknowledges it
+func (g *Game) ApplyLocalInput(input PlayerInput) {
+	player, exists := g.Players[g.LocalPlayerID]
+	if !exists || player.eliminated {
+		return
+	}
+
+	// Shooting cooldown ticks every frame, even without input (an authority
+	// ticks every player's cooldown itself)
+	if !g.Authoritative {
+		coolDown(player)
+	}
+	// A coasting tank keeps moving without input
+	aimed := input.Aim != player.TurretAngle
+	moving := input.MoveX != 0 || input.MoveY != 0 || player.Speed != 0
+	switched := input.Weapon != "" && input.Weapon != player.Weapon
+	if !moving && !input.Fire && !aimed && !switched {
+		return
+	}
+
+	g.inputSeq++
+	input.Seq = g.inputSeq
+	g.Recorder.Record(RecordInput, input)
+
+	g.pendingInputs = append(g.pendingInputs, input)
+	if len(g.pendingInputs) > MaxPendingInputs {
+		g.pendingInputs = g.pendingInputs[len(g.pendingInputs)-MaxPendingInputs:]
+	}
+
+	// In the plain mesh we move our own tank and tell the peers
+	if !g.Authoritative && !g.Client {
+		g.applyPeerInput(player, input)
+		return
+	}
+
+	g.applyMovement(player, input)
+	switchWeapon(player, input.Weapon)
+
+	// We are the elected host: the input is applied, just acknowledge it
+	if g.Authoritative {
+		g.pendingInputs = nil
+		g.ackInput(player.ID, input.Seq)
+		if input.Fire {
+			g.tryFire(player)
+		}
+		return
+	}
+
+	// A dedicated server or host owns movement and shooting, it only needs the input
+	g.sendInput(player.ID, input)
+}
+
+// applyPeerInput moves a player this peer speaks for in the plain mesh and
+// shares the result with the other peers. The local player and this
+// game's bots both go through it.
+func (g *Game) applyPeerInput(player *Player, input PlayerInput) {
+	// A coasting tank keeps moving without input
+	aimed := input.Aim != player.TurretAngle
+	moving := input.MoveX != 0 || input.MoveY != 0 || player.Speed != 0
+	switched := input.Weapon != "" && input.Weapon != player.Weapon
+	g.applyMovement(player, input)
+	switchWeapon(player, input.Weapon)
+
+	// Send movement update to peers
+	if moving || aimed || switched {
+		g.sendMovementUpdate(player)
+	}
+
+	// Shooting Mechanism
+	if input.Fire {
+		g.tryFire(player)
+	}
+}
+
+// reconcile rewinds the local player to an authoritative state and replays
+// every input the authority has not processed yet
+func (g *Game) reconcile(player *Player, msg MovementMessage) {
+	predictedX, predictedY := player.X, player.Y
+
+	player.X = msg.X
+	player.Y = msg.Y
+	player.Angle = msg.Angle
+	player.TurretAngle = msg.Turret
+	player.Speed = msg.Speed
+	player.Weapon = msg.Weapon
+
+	// Drop inputs the authority has already applied
+	remaining := g.pendingInputs[:0]
+	for _, input := range g.pendingInputs {
+		if input.Seq > msg.Seq {
+			remaining = append(remaining, input)
+		}
+	}
+	g.pendingInputs = remaining
+
+	for _, input := range g.pendingInputs {
+		g.applyMovement(player, input)
+		if input.Weapon != "" {
+			player.Weapon = input.Weapon
+		}
+	}
+
+	// Smooth the difference between what was shown and the corrected state
+	g.correctionX += predictedX - player.X
+	g.correctionY += predictedY - player.Y
+	if math.Hypot(g.correctionX, g.correctionY) > SnapDistance {
+		g.correctionX, g.correctionY = 0, 0
+	}
+}
+
+// PendingInputs returns the number of inputs not yet acknowledged
+func (g *Game) PendingInputs() int {
+	return len(g.pendingInputs)
+}
+
+// decayCorrection shrinks the visual correction towards zero
+func (g *Game) decayCorrection() {
+	g.correctionX *= CorrectionDecay
+	g.correctionY *= CorrectionDecay
+	if math.Abs(g.correctionX) < 0.01 {
+		g.correctionX = 0
+	}
+	if math.Abs(g.correctionY) < 0.01 {
+		g.correctionY = 0
+	}
+}
+
+// renderPosition returns where a player should be drawn, including any
+// correction still being smoothed out for the local player
+func (g *Game) renderPosition(player *Player) (float64, float64) {
+	if player.ID == g.LocalPlayerID {
+		return player.X + g.correctionX, player.Y + g.correctionY
+	}
+	return player.X, player.Y
+}
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// RebindKey opens and closes the controls screen
+const RebindKey = ebiten.KeyF1
+
+// rebindScreen is the in-game controls menu state
+type rebindScreen struct {
+	open      bool
+	selected  int    // Index into Actions
+	capturing bool   // Waiting for the next input to bind
+	status    string // Result of the last change, shown under the list
+}
+
+// controls returns the local player's bindings, the defaults if none were set
+func (g *Game) controls() *Controls {
+	if g.Controls == nil {
+		g.Controls = DefaultControls()
+	}
+	return g.Controls
+}
+
+// updateRebind runs the controls screen and reports whether it is open,
+// in which case it owns the keyboard and the tank sits still
+func (g *Game) updateRebind() bool {
+	menu := &g.rebind
+	if !menu.open {
+		if inpututil.IsKeyJustPressed(RebindKey) {
+			*menu = rebindScreen{open: true}
+		}
+		return menu.open
+	}
+
+	controls := g.controls()
+	action := Actions[menu.selected]
+	if menu.capturing {
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			menu.capturing = false
+			menu.status = "Cancelled"
+			return true
+		}
+		if b, ok := justPressedBinding(); ok {
+			menu.capturing = false
+			if err := controls.Bind(action, b); err != nil {
+				menu.status = err.Error()
+			} else {
+				menu.status = fmt.Sprintf("Bound %s to %s", b, action)
+			}
+		}
+		return true
+	}
+
+	switch {
+	case inpututil.IsKeyJustPressed(RebindKey), inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		menu.open = false
+		if g.ControlsPath != "" {
+			if err := controls.Save(g.ControlsPath); err != nil {
+				fmt.Println("Error saving controls:", err)
+			}
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		menu.selected = (menu.selected + len(Actions) - 1) % len(Actions)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		menu.selected = (menu.selected + 1) % len(Actions)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		menu.capturing = true
+		menu.status = ""
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		controls.Clear(action)
+		menu.status = fmt.Sprintf("Cleared %s", action)
+	case inpututil.IsKeyJustPressed(ebiten.KeyR):
+		*controls = *DefaultControls()
+		menu.status = "Restored the default controls"
+	}
+	return true
+}
+
+// justPressedBinding returns the first key, mouse button or gamepad
+// button pressed this frame
+func justPressedBinding() (Binding, bool) {
+	if keys := inpututil.AppendJustPressedKeys(nil); len(keys) > 0 {
+		return Binding{DeviceKey, keys[0].String()}, true
+	}
+	for name, button := range mouseButtons {
+		if inpututil.IsMouseButtonJustPressed(button) {
+			return Binding{DeviceMouse, name}, true
+		}
+	}
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		for name, button := range padButtons {
+			if inpututil.IsStandardGamepadButtonJustPressed(id, button) {
+				return Binding{DevicePad, name}, true
+			}
+		}
+	}
+	return Binding{}, false
+}
+
+// drawRebind renders the controls screen over the arena
+func (g *Game) drawRebind(screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 200})
+
+	var text strings.Builder
+	text.WriteString("CONTROLS\n\n")
+	for i, action := range Actions {
+		cursor := "  "
+		if i == g.rebind.selected {
+			cursor = "> "
+		}
+		var names []string
+		for _, b := range g.controls().Bindings[action] {
+			names = append(names, b.String())
+		}
+		fmt.Fprintf(&text, "%s%-6s %s\n", cursor, action, strings.Join(names, ", "))
+	}
+	text.WriteString("\n")
+	if g.rebind.capturing {
+		fmt.Fprintf(&text, "Press a key, mouse button or gamepad button for %s (Esc cancels)\n", Actions[g.rebind.selected])
+	} else {
+		text.WriteString("Up/Down: select  Enter: add binding  Backspace: clear  R: defaults  F1/Esc: close\n")
+	}
+	text.WriteString(g.rebind.status)
+	ebitenutil.DebugPrintAt(screen, text.String(), 40, 40)
+}
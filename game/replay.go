@@ -0,0 +1,282 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Kinds of replay events
+const (
+	RecordStart = "start" // First event, Data holds the recording player's ID
+	RecordIn    = "in"    // Message received from a peer
+	RecordOut   = "out"   // Message sent to peers
+	RecordInput = "input" // Local PlayerInput
+)
+
+// Replay playback settings
+const (
+	ReplayStep     = time.Second / 60 // Simulation step, matches the game's frame rate
+	ReplaySeekStep = 5 * time.Second  // Jump for the Left/Right keys
+	MinReplaySpeed = 0.25
+	MaxReplaySpeed = 8
+)
+
+// ReplayEvent is one line of a replay file
+type ReplayEvent struct {
+	At   time.Duration   `json:"t"` // Time since the recording started
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Recorder writes match events to a replay file, one JSON object per line.
+// A nil Recorder records nothing.
+type Recorder struct {
+	mutex   sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	start   time.Time
+}
+
+// NewRecorder creates a replay file for the given player
+func NewRecorder(path, playerID string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{file: file, encoder: json.NewEncoder(file), start: time.Now()}
+	r.Record(RecordStart, playerID)
+	return r, nil
+}
+
+// Record logs a message or input
+func (r *Recorder) Record(kind string, msg interface{}) {
+	if r == nil {
+		return
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		fmt.Println("Error encoding replay event:", err)
+		return
+	}
+	r.RecordRaw(kind, data)
+}
+
+// RecordRaw logs an already encoded message
+func (r *Recorder) RecordRaw(kind string, data []byte) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	event := ReplayEvent{At: time.Since(r.start), Kind: kind, Data: append(json.RawMessage(nil), data...)}
+	if err := r.encoder.Encode(event); err != nil {
+		fmt.Println("Error writing replay event:", err)
+	}
+}
+
+// Close flushes and closes the replay file
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.file.Close()
+}
+
+// LoadReplay reads every event from a replay file
+func LoadReplay(path string) ([]ReplayEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []ReplayEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event ReplayEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("bad replay event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// Replay plays a recorded match back through a Game.
+// Space pauses, Left/Right seek, Up/Down change the speed.
+type Replay struct {
+	Events   []ReplayEvent
+	PlayerID string // Player who recorded the match
+
+	world     *Game
+	host      string        // Authority whose snapshots are being played
+	next      int           // Index of the next event to apply
+	at        time.Duration // Playback position
+	speed     float64
+	paused    bool
+	steps     float64     // Fractional simulation steps owed at this speed
+	lastInput PlayerInput // Recorder's latest input, shown in the HUD
+}
+
+// NewReplay prepares a replay positioned at the start
+func NewReplay(events []ReplayEvent) *Replay {
+	r := &Replay{Events: events, speed: 1}
+	for _, event := range events {
+		if event.Kind == RecordStart {
+			json.Unmarshal(event.Data, &r.PlayerID)
+			break
+		}
+	}
+	r.reset()
+	return r
+}
+
+// World returns the game state at the current playback position
+func (r *Replay) World() *Game {
+	return r.world
+}
+
+// Position returns the current playback position
+func (r *Replay) Position() time.Duration {
+	return r.at
+}
+
+// Duration returns the length of the recording
+func (r *Replay) Duration() time.Duration {
+	if len(r.Events) == 0 {
+		return 0
+	}
+	return r.Events[len(r.Events)-1].At
+}
+
+// reset rewinds to an empty world, nobody is local so every recorded
+// player is shown as it was seen on the network
+func (r *Replay) reset() {
+	r.world = &Game{Players: make(map[string]*Player)}
+	r.host = ""
+	r.next = 0
+	r.at = 0
+	r.steps = 0
+}
+
+// step advances playback by one simulation step
+func (r *Replay) step() {
+	r.at += ReplayStep
+	for r.next < len(r.Events) && r.Events[r.next].At <= r.at {
+		event := r.Events[r.next]
+		switch event.Kind {
+		case RecordIn, RecordOut:
+			r.follow(event.Data)
+			r.world.HandleMessage(event.Data)
+		case RecordInput:
+			json.Unmarshal(event.Data, &r.lastInput)
+		}
+		r.next++
+	}
+	r.world.UpdateBullets()
+	r.world.updateRemovals()
+}
+
+// follow starts counting ticks afresh when snapshots come from a new host,
+// as the client did after a host migration
+func (r *Replay) follow(data []byte) {
+	var envelope struct {
+		Type string `json:"type"`
+		Host string `json:"host"`
+	}
+	if json.Unmarshal(data, &envelope) != nil || envelope.Type != "snapshot" && envelope.Type != "delta" {
+		return
+	}
+	if envelope.Host != r.host {
+		r.host = envelope.Host
+		r.world.tick = 0
+	}
+}
+
+// Seek moves playback to a position, replaying from the start when going back
+func (r *Replay) Seek(to time.Duration) {
+	if to < 0 {
+		to = 0
+	}
+	if to > r.Duration() {
+		to = r.Duration()
+	}
+	if to < r.at {
+		r.reset()
+	}
+	for r.at < to {
+		r.step()
+	}
+}
+
+// Update handles playback controls and advances time
+func (r *Replay) Update() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		r.paused = !r.paused
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+		r.Seek(r.at + ReplaySeekStep)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+		r.Seek(r.at - ReplaySeekStep)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && r.speed < MaxReplaySpeed {
+		r.speed *= 2
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) && r.speed > MinReplaySpeed {
+		r.speed /= 2
+	}
+
+	if r.paused || r.at >= r.Duration() {
+		return nil
+	}
+	r.steps += r.speed
+	for ; r.steps >= 1; r.steps-- {
+		r.step()
+	}
+	return nil
+}
+
+// Draw renders the recorded world with a playback HUD
+func (r *Replay) Draw(screen *ebiten.Image) {
+	r.world.Draw(screen)
+
+	state := "playing"
+	if r.paused {
+		state = "paused"
+	}
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Replay of %s  %s / %s  %.2gx %s\nInput: move (%.0f, %.0f) fire %v\nSpace pause  Left/Right seek  Up/Down speed",
+		r.PlayerID, r.at.Truncate(time.Second), r.Duration().Truncate(time.Second), r.speed, state,
+		r.lastInput.MoveX, r.lastInput.MoveY, r.lastInput.Fire))
+}
+
+// Layout defines the screen size
+func (r *Replay) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return ScreenWidth, ScreenHeight
+}
+
+// RunReplay opens a replay window for a recorded match
+func RunReplay(path string) error {
+	events, err := LoadReplay(path)
+	if err != nil {
+		return err
+	}
+
+	LoadAssets()
+	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
+	ebiten.SetWindowTitle("2D Battle Royale - Replay")
+	return ebiten.RunGame(NewReplay(events))
+}
@@ -0,0 +1,383 @@
+package game
+
+import (
+	"sort"
+)
+
+// SnapshotHistory is how many past snapshots are kept to diff against
+// (~1s at 60 ticks). A peer whose last ack is older gets a full snapshot.
+const SnapshotHistory = 64
+
+// BulletState describes a bullet in a snapshot by where it was at Tick.
+// Bullets fly in straight lines, so the entry stays the same for the whole
+// flight and costs nothing in a delta.
+type BulletState struct {
+	ID      uint32  `json:"id"`
+	OwnerID string  `json:"owner_id"`
+	Weapon  string  `json:"weapon,omitempty"`
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	VX      float64 `json:"vx"`
+	VY      float64 `json:"vy"`
+	Tick    uint32  `json:"tick"`
+}
+
+// BulletRef identifies a bullet across snapshots
+type BulletRef struct {
+	ID      uint32 `json:"id"`
+	OwnerID string `json:"owner_id"`
+}
+
+// PlayerDelta holds only the fields of a player that changed since the
+// base snapshot, a new player has all of them
+type PlayerDelta struct {
+	ID         string   `json:"id"`
+	X          *float64 `json:"x,omitempty"`
+	Y          *float64 `json:"y,omitempty"`
+	Angle      *float64 `json:"angle,omitempty"`
+	Turret     *float64 `json:"turret,omitempty"`
+	Speed      *float64 `json:"speed,omitempty"`
+	Weapon     *string  `json:"weapon,omitempty"`
+	Health     *int     `json:"health,omitempty"`
+	Shield     *int     `json:"shield,omitempty"`
+	Score      *int     `json:"score,omitempty"`
+	Team       *int     `json:"team,omitempty"`
+	Boost      *int     `json:"boost,omitempty"`
+	Rapid      *int     `json:"rapid,omitempty"`
+	Seq        *uint32  `json:"seq,omitempty"`
+	Eliminated *bool    `json:"eliminated,omitempty"`
+}
+
+// DeltaMessage struct (a snapshot encoded against one the receiver acked)
+type DeltaMessage struct {
+	Type           string        `json:"type"` // "delta"
+	Host           string        `json:"host,omitempty"`
+	Tick           uint32        `json:"tick"`
+	Base           uint32        `json:"base"` // Tick of the snapshot this applies to
+	Match          MatchConfig   `json:"match"`
+	Players        []PlayerDelta `json:"players,omitempty"`
+	Removed        []string      `json:"removed,omitempty"`
+	Bullets        []BulletState `json:"bullets,omitempty"` // Spawned or changed
+	RemovedBullets []BulletRef   `json:"removed_bullets,omitempty"`
+	Pickups        []PickupState `json:"pickups,omitempty"` // Spawned or changed
+	RemovedPickups []int         `json:"removed_pickups,omitempty"`
+	Teams          []int         `json:"teams,omitempty"` // Hill points, only when they changed
+}
+
+// AckMessage struct (sent by a client for every snapshot it applied)
+type AckMessage struct {
+	Type string `json:"type"` // "ack"
+	ID   string `json:"id"`
+	Tick uint32 `json:"tick"`
+}
+
+// DiffSnapshots encodes cur as the changes since base
+func DiffSnapshots(base, cur SnapshotMessage) DeltaMessage {
+	delta := DeltaMessage{Type: "delta", Host: cur.Host, Tick: cur.Tick, Base: base.Tick, Match: cur.Match}
+
+	old := make(map[string]PlayerState, len(base.Players))
+	for _, p := range base.Players {
+		old[p.ID] = p
+	}
+	for _, p := range cur.Players {
+		prev, existed := old[p.ID]
+		delete(old, p.ID)
+
+		d := PlayerDelta{ID: p.ID}
+		changed := !existed
+		if !existed || p.X != prev.X {
+			d.X, changed = &p.X, true
+		}
+		if !existed || p.Y != prev.Y {
+			d.Y, changed = &p.Y, true
+		}
+		if !existed || p.Angle != prev.Angle {
+			d.Angle, changed = &p.Angle, true
+		}
+		if !existed || p.Turret != prev.Turret {
+			d.Turret, changed = &p.Turret, true
+		}
+		if !existed || p.Speed != prev.Speed {
+			d.Speed, changed = &p.Speed, true
+		}
+		if !existed || p.Weapon != prev.Weapon {
+			d.Weapon, changed = &p.Weapon, true
+		}
+		if !existed || p.Health != prev.Health {
+			d.Health, changed = &p.Health, true
+		}
+		if !existed || p.Shield != prev.Shield {
+			d.Shield, changed = &p.Shield, true
+		}
+		if !existed || p.Score != prev.Score {
+			d.Score, changed = &p.Score, true
+		}
+		if !existed || p.Team != prev.Team {
+			d.Team, changed = &p.Team, true
+		}
+		if !existed || p.Boost != prev.Boost {
+			d.Boost, changed = &p.Boost, true
+		}
+		if !existed || p.Rapid != prev.Rapid {
+			d.Rapid, changed = &p.Rapid, true
+		}
+		if !existed || p.Seq != prev.Seq {
+			d.Seq, changed = &p.Seq, true
+		}
+		if !existed || p.Eliminated != prev.Eliminated {
+			d.Eliminated, changed = &p.Eliminated, true
+		}
+		if changed {
+			delta.Players = append(delta.Players, d)
+		}
+	}
+	for id := range old {
+		delta.Removed = append(delta.Removed, id)
+	}
+	sort.Strings(delta.Removed)
+
+	oldBullets := make(map[BulletRef]BulletState, len(base.Bullets))
+	for _, b := range base.Bullets {
+		oldBullets[BulletRef{ID: b.ID, OwnerID: b.OwnerID}] = b
+	}
+	for _, b := range cur.Bullets {
+		ref := BulletRef{ID: b.ID, OwnerID: b.OwnerID}
+		if prev, existed := oldBullets[ref]; !existed || prev != b {
+			delta.Bullets = append(delta.Bullets, b)
+		}
+		delete(oldBullets, ref)
+	}
+	for ref := range oldBullets {
+		delta.RemovedBullets = append(delta.RemovedBullets, ref)
+	}
+	sortBulletRefs(delta.RemovedBullets)
+
+	oldPickups := make(map[int]PickupState, len(base.Pickups))
+	for _, p := range base.Pickups {
+		oldPickups[p.ID] = p
+	}
+	for _, p := range cur.Pickups {
+		if prev, existed := oldPickups[p.ID]; !existed || prev != p {
+			delta.Pickups = append(delta.Pickups, p)
+		}
+		delete(oldPickups, p.ID)
+	}
+	for id := range oldPickups {
+		delta.RemovedPickups = append(delta.RemovedPickups, id)
+	}
+	sort.Ints(delta.RemovedPickups)
+
+	if !equalInts(base.Teams, cur.Teams) {
+		delta.Teams = cur.Teams
+	}
+	return delta
+}
+
+// PatchSnapshot rebuilds the full snapshot a delta was made from
+func PatchSnapshot(base SnapshotMessage, delta DeltaMessage) SnapshotMessage {
+	players := make(map[string]PlayerState, len(base.Players))
+	for _, p := range base.Players {
+		players[p.ID] = p
+	}
+	for _, d := range delta.Players {
+		p := players[d.ID]
+		p.ID = d.ID
+		if d.X != nil {
+			p.X = *d.X
+		}
+		if d.Y != nil {
+			p.Y = *d.Y
+		}
+		if d.Angle != nil {
+			p.Angle = *d.Angle
+		}
+		if d.Turret != nil {
+			p.Turret = *d.Turret
+		}
+		if d.Speed != nil {
+			p.Speed = *d.Speed
+		}
+		if d.Weapon != nil {
+			p.Weapon = *d.Weapon
+		}
+		if d.Health != nil {
+			p.Health = *d.Health
+		}
+		if d.Shield != nil {
+			p.Shield = *d.Shield
+		}
+		if d.Score != nil {
+			p.Score = *d.Score
+		}
+		if d.Team != nil {
+			p.Team = *d.Team
+		}
+		if d.Boost != nil {
+			p.Boost = *d.Boost
+		}
+		if d.Rapid != nil {
+			p.Rapid = *d.Rapid
+		}
+		if d.Seq != nil {
+			p.Seq = *d.Seq
+		}
+		if d.Eliminated != nil {
+			p.Eliminated = *d.Eliminated
+		}
+		players[d.ID] = p
+	}
+	for _, id := range delta.Removed {
+		delete(players, id)
+	}
+
+	bullets := make(map[BulletRef]BulletState, len(base.Bullets))
+	for _, b := range base.Bullets {
+		bullets[BulletRef{ID: b.ID, OwnerID: b.OwnerID}] = b
+	}
+	for _, b := range delta.Bullets {
+		bullets[BulletRef{ID: b.ID, OwnerID: b.OwnerID}] = b
+	}
+	for _, ref := range delta.RemovedBullets {
+		delete(bullets, ref)
+	}
+
+	pickups := make(map[int]PickupState, len(base.Pickups))
+	for _, p := range base.Pickups {
+		pickups[p.ID] = p
+	}
+	for _, p := range delta.Pickups {
+		pickups[p.ID] = p
+	}
+	for _, id := range delta.RemovedPickups {
+		delete(pickups, id)
+	}
+
+	snap := SnapshotMessage{Type: "snapshot", Host: delta.Host, Tick: delta.Tick, Match: delta.Match, Teams: base.Teams}
+	if delta.Teams != nil {
+		snap.Teams = delta.Teams
+	}
+	for _, p := range players {
+		snap.Players = append(snap.Players, p)
+	}
+	for _, b := range bullets {
+		snap.Bullets = append(snap.Bullets, b)
+	}
+	for _, p := range pickups {
+		snap.Pickups = append(snap.Pickups, p)
+	}
+	sortSnapshot(&snap)
+	return snap
+}
+
+// sortSnapshot orders players and bullets so equal worlds encode equally
+func sortSnapshot(snap *SnapshotMessage) {
+	sort.Slice(snap.Players, func(i, j int) bool {
+		return snap.Players[i].ID < snap.Players[j].ID
+	})
+	sort.Slice(snap.Bullets, func(i, j int) bool {
+		if snap.Bullets[i].OwnerID != snap.Bullets[j].OwnerID {
+			return snap.Bullets[i].OwnerID < snap.Bullets[j].OwnerID
+		}
+		return snap.Bullets[i].ID < snap.Bullets[j].ID
+	})
+	sort.Slice(snap.Pickups, func(i, j int) bool {
+		return snap.Pickups[i].ID < snap.Pickups[j].ID
+	})
+}
+
+// equalInts reports whether two int slices hold the same values
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortBulletRefs(refs []BulletRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].OwnerID != refs[j].OwnerID {
+			return refs[i].OwnerID < refs[j].OwnerID
+		}
+		return refs[i].ID < refs[j].ID
+	})
+}
+
+// remember stores a snapshot so later deltas can be made or applied
+// against it, must hold mutex
+func (g *Game) remember(snap SnapshotMessage) {
+	g.history[snap.Tick%SnapshotHistory] = snap
+}
+
+// recalled returns a remembered snapshot, must hold mutex
+func (g *Game) recalled(tick uint32) (SnapshotMessage, bool) {
+	snap := g.history[tick%SnapshotHistory]
+	return snap, tick != 0 && snap.Tick == tick
+}
+
+// SnapshotFor encodes a snapshot for one client: a delta against the last
+// snapshot it acknowledged, or the full snapshot if that one is unknown
+// (never acked, lost for too long, or out of history)
+func (g *Game) SnapshotFor(id string, snap SnapshotMessage) interface{} {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	base, ok := g.recalled(g.acked[id])
+	if !ok {
+		return snap
+	}
+	delta := DiffSnapshots(base, snap)
+	delta.Host = snap.Host
+	return delta
+}
+
+// ackSnapshot records the newest snapshot a client has applied
+func (g *Game) ackSnapshot(msg AckMessage) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.acked == nil {
+		g.acked = make(map[string]uint32)
+	}
+	if msg.Tick > g.acked[msg.ID] && msg.Tick <= g.tick {
+		g.acked[msg.ID] = msg.Tick
+	}
+}
+
+// ApplyDelta rebuilds a snapshot from a delta and applies it. Deltas
+// against a snapshot we never got are dropped, the missing ack makes the
+// sender fall back to a full snapshot.
+func (g *Game) ApplyDelta(msg DeltaMessage) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	base, ok := g.recalled(msg.Base)
+	if !ok {
+		return
+	}
+	g.applySnapshot(PatchSnapshot(base, msg))
+}
+
+// sendAck acknowledges an applied snapshot to its sender
+func (g *Game) sendAck(tick uint32) {
+	if g.SendUpdate != nil && g.LocalPlayerID != "" {
+		g.SendUpdate(AckMessage{Type: "ack", ID: g.LocalPlayerID, Tick: tick})
+	}
+}
+
+// sendSnapshot shares a new authoritative snapshot with every client
+func (g *Game) sendSnapshot(snap SnapshotMessage, clients []string) {
+	if g.SendTo == nil {
+		if g.SendUpdate != nil {
+			g.SendUpdate(snap)
+		}
+		return
+	}
+	for _, id := range clients {
+		g.SendTo(id, g.SnapshotFor(id, snap))
+	}
+}
@@ -0,0 +1,114 @@
+package game_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Delta Round Trip**
+func TestDeltaRoundTrip(t *testing.T) {
+	base := game.SnapshotMessage{Type: "snapshot", Tick: 10,
+		Players: []game.PlayerState{
+			{ID: "a", X: 10, Y: 10, Health: 100, Seq: 3},
+			{ID: "b", X: 50, Y: 60, Angle: 1.5, Health: 80, Seq: 7},
+			{ID: "c", X: 90, Y: 90, Health: 5},
+		},
+		Bullets: []game.BulletState{
+			{ID: 1, OwnerID: "a", X: 10, Y: 10, VX: 4, Tick: 8},
+			{ID: 2, OwnerID: "b", X: 50, Y: 60, VY: 4, Tick: 9},
+		},
+	}
+	cur := game.SnapshotMessage{Type: "snapshot", Tick: 12,
+		Players: []game.PlayerState{
+			{ID: "a", X: 12, Y: 10, Health: 100, Seq: 4},            // Moved
+			{ID: "b", X: 50, Y: 60, Angle: 1.5, Health: 80, Seq: 7}, // Unchanged
+			{ID: "d", X: 200, Y: 300, Health: 100},                  // Joined, "c" left
+		},
+		Bullets: []game.BulletState{
+			{ID: 1, OwnerID: "a", X: 10, Y: 10, VX: 4, Tick: 8},
+			{ID: 3, OwnerID: "d", X: 200, Y: 300, VX: -4, Tick: 11},
+		},
+	}
+
+	delta := game.DiffSnapshots(base, cur)
+	if len(delta.Players) != 2 || len(delta.Removed) != 1 || len(delta.Bullets) != 1 || len(delta.RemovedBullets) != 1 {
+		t.Fatalf("Unexpected delta contents %+v", delta)
+	}
+
+	// Through the wire and back
+	data, err := json.Marshal(delta)
+	if err != nil {
+		t.Fatalf("Failed to encode delta: %v", err)
+	}
+	var decoded game.DeltaMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode delta: %v", err)
+	}
+
+	rebuilt := game.PatchSnapshot(base, decoded)
+	if !reflect.DeepEqual(rebuilt, cur) {
+		t.Errorf("Rebuilt snapshot differs\ngot  %+v\nwant %+v", rebuilt, cur)
+	}
+
+	full, _ := json.Marshal(cur)
+	if len(data) >= len(full) {
+		t.Errorf("Expected delta (%d bytes) to be smaller than full snapshot (%d bytes)", len(data), len(full))
+	}
+}
+
+// ** Test Full Snapshot Fallback**
+func TestSnapshotFallsBackToFull(t *testing.T) {
+	server := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+	}
+	server.AddPlayer("client1")
+	first := server.Tick()
+
+	// Nothing acknowledged yet: full snapshot
+	if _, ok := server.SnapshotFor("client1", first).(game.SnapshotMessage); !ok {
+		t.Fatalf("Expected a full snapshot before any ack")
+	}
+
+	// A client applies it and acks, the next one is a delta against it
+	var acks []interface{}
+	client := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "client1",
+		Client:        true,
+		SendUpdate:    func(msg interface{}) { acks = append(acks, msg) },
+	}
+	client.ApplySnapshot(first)
+	if len(acks) != 1 {
+		t.Fatalf("Expected the client to ack the snapshot")
+	}
+	ackData, _ := json.Marshal(acks[0])
+	server.HandleMessage(ackData)
+
+	second := server.Tick()
+	delta, ok := server.SnapshotFor("client1", second).(game.DeltaMessage)
+	if !ok || delta.Base != first.Tick {
+		t.Fatalf("Expected a delta against tick %d", first.Tick)
+	}
+	client.ApplyDelta(delta)
+	if len(acks) != 2 {
+		t.Errorf("Expected the client to ack the delta")
+	}
+
+	// A delta against a snapshot the client never got is dropped
+	client.ApplyDelta(game.DeltaMessage{Type: "delta", Tick: 99, Base: 50})
+	if len(acks) != 2 {
+		t.Errorf("Expected no ack for a delta with unknown base")
+	}
+
+	// After a long loss the acked snapshot is gone from history
+	for i := 0; i < game.SnapshotHistory+1; i++ {
+		second = server.Tick()
+	}
+	if _, ok := server.SnapshotFor("client1", second).(game.SnapshotMessage); !ok {
+		t.Errorf("Expected a full snapshot once the acked one left history")
+	}
+}
@@ -0,0 +1,120 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// FollowZoom is how far the spectator camera zooms in on a followed player
+const FollowZoom = 2.0
+
+// SpectateMessage struct (sent by a spectator so a server or host sends it
+// snapshots, it never spawns a tank or counts in host election)
+type SpectateMessage struct {
+	Type string `json:"type"` // "spectate"
+	ID   string `json:"id"`
+}
+
+// watching records that a spectator is still connected
+func (g *Game) watching(id string) {
+	if id == "" {
+		return
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.spectators == nil {
+		g.spectators = make(map[string]time.Time)
+	}
+	g.spectators[id] = g.now()
+}
+
+// watchers lists spectators heard from recently, must hold mutex
+func (g *Game) watchers() []string {
+	var ids []string
+	for id, last := range g.spectators {
+		if g.now().Sub(last) < HostTimeout {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// updateSpectatorCamera cycles the follow-cam with Tab: every player in
+// turn, then the whole arena
+func (g *Game) updateSpectatorCamera() {
+	g.mutex.Lock()
+	ids := make([]string, 0, len(g.Players))
+	for id := range g.Players {
+		ids = append(ids, id)
+	}
+	g.mutex.Unlock()
+	sort.Strings(ids)
+
+	// The followed player may have left
+	if g.followID != "" && g.Players[g.followID] == nil {
+		g.followID = ""
+	}
+	if !inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		return
+	}
+	g.followID = nextFollow(ids, g.followID)
+}
+
+// nextFollow returns the player after current, or "" (whole arena) after
+// the last one
+func nextFollow(ids []string, current string) string {
+	if current == "" {
+		if len(ids) == 0 {
+			return ""
+		}
+		return ids[0]
+	}
+	for i, id := range ids {
+		if id == current && i+1 < len(ids) {
+			return ids[i+1]
+		}
+	}
+	return ""
+}
+
+// drawSpectator renders the arena through the spectator camera
+func (g *Game) drawSpectator(screen *ebiten.Image) {
+	if g.worldImage == nil {
+		g.worldImage = ebiten.NewImage(ScreenWidth, ScreenHeight)
+	}
+	g.worldImage.Clear()
+	g.drawWorld(g.worldImage)
+
+	op := &ebiten.DrawImageOptions{}
+	label := "whole arena"
+	if target := g.Players[g.followID]; target != nil {
+		// Center the followed player, without showing outside the arena
+		camX := clamp(target.X-ScreenWidth/(2*FollowZoom), 0, ScreenWidth-ScreenWidth/FollowZoom)
+		camY := clamp(target.Y-ScreenHeight/(2*FollowZoom), 0, ScreenHeight-ScreenHeight/FollowZoom)
+		op.GeoM.Translate(-camX, -camY)
+		op.GeoM.Scale(FollowZoom, FollowZoom)
+		label = fmt.Sprintf("%s (health %d)", target.ID, target.Health)
+	}
+	screen.Fill(color.RGBA{20, 20, 20, 255})
+	screen.DrawImage(g.worldImage, op)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("Spectating: %s  (%d players)\nTab: next player / whole arena", label, len(g.Players)))
+	g.drawScoreboard(screen)
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,217 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// MaxTeams is how many teams a match can have, one per team color
+const MaxTeams = 4
+
+// King of the hill settings
+const (
+	HillRadius   = 70 // Size of the hill in the middle of the arena
+	HillInterval = 60 // Frames a team must hold the hill alone to score a point
+)
+
+// TeamNames and TeamColors describe teams 1..MaxTeams (index team-1)
+var (
+	TeamNames  = []string{"Red", "Blue", "Green", "Yellow"}
+	TeamColors = []color.RGBA{{255, 90, 90, 255}, {90, 140, 255, 255}, {90, 230, 90, 255}, {255, 230, 80, 255}}
+)
+
+// teamName returns a team's display name
+func teamName(team int) string {
+	if team < 1 || team > len(TeamNames) {
+		return "No team"
+	}
+	return TeamNames[team-1]
+}
+
+// pickTeam returns the requested team if the match has it, otherwise the
+// team with the fewest tanks (lowest number on a tie), must hold mutex
+func (g *Game) pickTeam(requested int) int {
+	if g.Match.Teams == 0 {
+		return 0
+	}
+	if requested >= 1 && requested <= g.Match.Teams {
+		return requested
+	}
+	counts := make([]int, g.Match.Teams+1)
+	for _, p := range g.Players {
+		if p.Team >= 1 && p.Team <= g.Match.Teams {
+			counts[p.Team]++
+		}
+	}
+	best := 1
+	for team := 2; team <= g.Match.Teams; team++ {
+		if counts[team] < counts[best] {
+			best = team
+		}
+	}
+	return best
+}
+
+// rebalanceTeams evens out auto-balanced teams in the mesh, must hold mutex.
+// Peers that start together pick a team before hearing from each other, so
+// they can all land on team 1. Once a team has two tanks more than another,
+// the highest ID on it that may move goes across: every peer agrees on who
+// that is, so only one tank moves at a time, and its owner moves it.
+func (g *Game) rebalanceTeams() {
+	if g.Match.Teams == 0 || g.Authoritative || g.Client || g.HostMode {
+		return
+	}
+	counts := make([]int, g.Match.Teams+1)
+	highest := make([]string, g.Match.Teams+1)
+	for id, p := range g.Players {
+		if p.Team < 1 || p.Team > g.Match.Teams {
+			continue
+		}
+		counts[p.Team]++
+		if !g.keepsTeam(p) && id > highest[p.Team] {
+			highest[p.Team] = id
+		}
+	}
+	smallest, largest := 1, 1
+	for team := 2; team <= g.Match.Teams; team++ {
+		if counts[team] < counts[smallest] {
+			smallest = team
+		}
+		if counts[team] > counts[largest] {
+			largest = team
+		}
+	}
+	if counts[largest]-counts[smallest] < 2 {
+		return
+	}
+
+	if id := highest[largest]; id != "" && g.ownsPlayer(id) {
+		player := g.Players[id]
+		player.Team = smallest
+		g.sendMovementUpdate(player)
+	}
+}
+
+// keepsTeam reports whether a player asked for its team with -team, so
+// rebalancing never moves it. Other peers say so in their moves.
+func (g *Game) keepsTeam(p *Player) bool {
+	if p.ID == g.LocalPlayerID {
+		return g.Team != 0
+	}
+	return p.fixedTeam
+}
+
+// Teammates reports whether two players are on the same team
+func (g *Game) Teammates(a, b string) bool {
+	if g.Match.Teams == 0 {
+		return false
+	}
+	pa, pb := g.Players[a], g.Players[b]
+	return pa != nil && pb != nil && pa.Team != 0 && pa.Team == pb.Team
+}
+
+// harmless reports whether a shot by shooter can't hurt victim
+func (g *Game) harmless(shooter, victim string) bool {
+	return !g.Match.FriendlyFire && g.Teammates(shooter, victim)
+}
+
+// teamScore returns a team's hill points in king of the hill, otherwise
+// the eliminations of its players
+func (g *Game) teamScore(team int) int {
+	if g.Match.Mode == KingOfTheHillMode {
+		if team >= 1 && team <= len(g.teamPoints) {
+			return g.teamPoints[team-1]
+		}
+		return 0
+	}
+	score := 0
+	for _, p := range g.Players {
+		if p.Team == team {
+			score += p.Score
+		}
+	}
+	return score
+}
+
+// leader returns who is winning: a team name with teams, otherwise a player ID
+func (g *Game) leader() string {
+	if g.Match.Teams == 0 {
+		if standings := g.standings(); len(standings) > 0 {
+			return standings[0].ID
+		}
+		return ""
+	}
+	best := 1
+	for team := 2; team <= g.Match.Teams; team++ {
+		if g.teamScore(team) > g.teamScore(best) {
+			best = team
+		}
+	}
+	return teamName(best) + " team"
+}
+
+// updateHill scores a point for a team holding the hill alone, must hold mutex
+func (g *Game) updateHill() {
+	if g.Match.Mode != KingOfTheHillMode || g.Client || g.elapsed()%HillInterval != 0 {
+		return
+	}
+	if len(g.teamPoints) != g.Match.Teams {
+		g.teamPoints = make([]int, g.Match.Teams)
+	}
+
+	holder := 0
+	for _, p := range g.Players {
+		if p.eliminated || p.Team < 1 || p.Team > g.Match.Teams || !onHill(p) {
+			continue
+		}
+		if holder != 0 && holder != p.Team {
+			return // Contested
+		}
+		holder = p.Team
+	}
+	if holder != 0 {
+		g.teamPoints[holder-1]++
+	}
+}
+
+// onHill reports whether a tank is on the hill
+func onHill(p *Player) bool {
+	return math.Hypot(p.X-ScreenWidth/2, p.Y-ScreenHeight/2) < HillRadius
+}
+
+// drawHill renders the hill, in the color of the team on it
+func (g *Game) drawHill(screen *ebiten.Image) {
+	if g.Match.Mode != KingOfTheHillMode {
+		return
+	}
+	c := color.RGBA{200, 200, 200, 255}
+	for _, p := range g.Players {
+		if !p.eliminated && p.Team >= 1 && p.Team <= len(TeamColors) && onHill(p) {
+			c = TeamColors[p.Team-1]
+			break
+		}
+	}
+	vector.StrokeCircle(screen, ScreenWidth/2, ScreenHeight/2, HillRadius, 3, c, true)
+}
+
+// tintTeam colors a tank sprite with its team color
+func tintTeam(op *ebiten.DrawImageOptions, team int) {
+	if team < 1 || team > len(TeamColors) {
+		return
+	}
+	c := TeamColors[team-1]
+	op.ColorScale.Scale(float32(c.R)/255, float32(c.G)/255, float32(c.B)/255, 1)
+}
+
+// teamLines lists team scores for the scoreboard
+func (g *Game) teamLines() string {
+	lines := ""
+	for team := 1; team <= g.Match.Teams; team++ {
+		lines += fmt.Sprintf("\n%s team: %d", teamName(team), g.teamScore(team))
+	}
+	return lines
+}
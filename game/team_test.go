@@ -0,0 +1,181 @@
+package game_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Team Auto-Balance**
+func TestJoiningPlayersAreBalanced(t *testing.T) {
+	server := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+		Match:         game.MatchConfig{Mode: game.DeathmatchMode, Teams: 2},
+	}
+	server.AddPlayerOnTeam("a", 1)
+	server.AddPlayerOnTeam("b", 1)
+	server.AddPlayer("c")
+	server.AddPlayerOnTeam("d", 7) // Not a team in this match
+
+	if server.Players["a"].Team != 1 || server.Players["b"].Team != 1 {
+		t.Errorf("Expected requested teams to be kept, got %d and %d", server.Players["a"].Team, server.Players["b"].Team)
+	}
+	if server.Players["c"].Team != 2 || server.Players["d"].Team != 2 {
+		t.Errorf("Expected the others on the smaller team, got %d and %d", server.Players["c"].Team, server.Players["d"].Team)
+	}
+}
+
+// ** Test Mesh Team Auto-Balance**
+func TestMeshPeersStartingTogetherSpreadOut(t *testing.T) {
+	// Each peer picked a team before hearing from the others, all team 1
+	ids := []string{"peer-a", "peer-b", "peer-c", "peer-d"}
+	peers := map[string]*game.Game{}
+	inbox := map[string][][]byte{}
+	for _, id := range ids {
+		from := id
+		peers[id] = &game.Game{
+			Players:         make(map[string]*game.Player),
+			LocalPlayerID:   id,
+			LocalController: idle{},
+			Match:           game.MatchConfig{Mode: game.DeathmatchMode, Teams: 2},
+			SendUpdate: func(msg interface{}) {
+				data, _ := json.Marshal(msg)
+				for _, to := range ids {
+					if to != from {
+						inbox[to] = append(inbox[to], data)
+					}
+				}
+			},
+		}
+		for _, other := range ids {
+			peers[id].Players[other] = &game.Player{ID: other, X: 100, Y: 100, Health: game.MaxHealth, Team: 1}
+		}
+	}
+
+	for frame := 0; frame < 10; frame++ {
+		for _, id := range ids {
+			peers[id].Update()
+		}
+		for _, id := range ids {
+			for _, data := range inbox[id] {
+				peers[id].HandleMessage(data)
+			}
+			inbox[id] = nil
+		}
+	}
+
+	for _, id := range ids {
+		counts := map[int]int{}
+		for _, other := range ids {
+			counts[peers[id].Players[other].Team]++
+			if team := peers[id].Players[other].Team; team != peers[other].Players[other].Team {
+				t.Errorf("%s sees %s on team %d, it is on %d", id, other, team, peers[other].Players[other].Team)
+			}
+		}
+		if counts[1] != 2 || counts[2] != 2 {
+			t.Errorf("Expected two tanks a team on %s, got %v", id, counts)
+		}
+	}
+}
+
+// ** Test Mesh Auto-Balance Skips Chosen Teams**
+func TestMeshRebalanceSkipsPlayersWhoChoseTheirTeam(t *testing.T) {
+	// peer-c, the highest ID, joined team 1 with -team and says so
+	var moved []game.MovementMessage
+	peers := map[string]*game.Game{}
+	for _, id := range []string{"peer-a", "peer-b"} {
+		peers[id] = &game.Game{
+			Players:         make(map[string]*game.Player),
+			LocalPlayerID:   id,
+			LocalController: idle{},
+			Match:           game.MatchConfig{Mode: game.DeathmatchMode, Teams: 2},
+			SendUpdate: func(msg interface{}) {
+				if move, ok := msg.(game.MovementMessage); ok {
+					moved = append(moved, move)
+				}
+			},
+		}
+		for _, other := range []string{"peer-a", "peer-b"} {
+			peers[id].Players[other] = &game.Player{ID: other, X: 100, Y: 100, Health: game.MaxHealth, Team: 1}
+		}
+		peers[id].HandleMessage([]byte(`{"type":"move","id":"peer-c","x":300,"y":300,"team":1,"fixed_team":true}`))
+	}
+
+	peers["peer-a"].Update()
+	peers["peer-b"].Update()
+	if len(moved) != 1 || moved[0].ID != "peer-b" || moved[0].Team != 2 {
+		t.Fatalf("Expected only peer-b to move to team 2, got %+v", moved)
+	}
+	if team := peers["peer-b"].Players["peer-b"].Team; team != 2 {
+		t.Errorf("Expected peer-b on team 2, got %d", team)
+	}
+	if team := peers["peer-a"].Players["peer-a"].Team; team != 1 {
+		t.Errorf("Expected peer-a to stay on team 1, got %d", team)
+	}
+}
+
+// ** Test Friendly Fire**
+func TestTeammateBulletsPassThroughWithoutFriendlyFire(t *testing.T) {
+	for _, friendlyFire := range []bool{false, true} {
+		server := &game.Game{
+			Players:       make(map[string]*game.Player),
+			Authoritative: true,
+			Match:         game.MatchConfig{Mode: game.DeathmatchMode, Teams: 2, FriendlyFire: friendlyFire},
+		}
+		server.Players["shooter"] = &game.Player{ID: "shooter", X: 400, Y: 400, Health: game.MaxHealth, Team: 1}
+		server.Players["mate"] = &game.Player{ID: "mate", X: 50, Y: 50, Health: game.DamageAmount, Team: 1}
+		server.Bullets = append(server.Bullets, game.Bullet{ID: 1, X: 55, Y: 55, Active: true, OwnerID: "shooter"})
+
+		server.Tick()
+		mate := server.Players["mate"]
+		if mate.Eliminated() != friendlyFire {
+			t.Errorf("Friendly fire %v: expected eliminated %v, got %v", friendlyFire, friendlyFire, mate.Eliminated())
+		}
+		if server.Players["shooter"].Score != 0 {
+			t.Errorf("Friendly fire %v: expected no score for hitting a teammate, got %d", friendlyFire, server.Players["shooter"].Score)
+		}
+	}
+}
+
+// ** Test King Of The Hill**
+func TestTeamHoldingTheHillScores(t *testing.T) {
+	server := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+		Match:         game.MatchConfig{Mode: game.KingOfTheHillMode, Teams: 2, ScoreLimit: 3},
+	}
+	server.Players["red"] = &game.Player{ID: "red", X: game.ScreenWidth / 2, Y: game.ScreenHeight / 2, Health: game.MaxHealth, Team: 1}
+	server.Players["blue"] = &game.Player{ID: "blue", X: 100, Y: 100, Health: game.MaxHealth, Team: 2}
+
+	var snap game.SnapshotMessage
+	for i := 0; i < 2*game.HillInterval; i++ {
+		snap = server.Tick()
+	}
+	if len(snap.Teams) != 2 || snap.Teams[0] != 2 || snap.Teams[1] != 0 {
+		t.Fatalf("Expected red to hold the hill for 2 points, got %v", snap.Teams)
+	}
+
+	// A contested hill scores nothing
+	server.Players["blue"].X, server.Players["blue"].Y = game.ScreenWidth/2+40, game.ScreenHeight/2
+	for i := 0; i < 2*game.HillInterval; i++ {
+		snap = server.Tick()
+	}
+	if snap.Teams[0] != 2 || snap.Teams[1] != 0 {
+		t.Errorf("Expected no points on a contested hill, got %v", snap.Teams)
+	}
+
+	// Deltas carry the points only when they change
+	base := snap
+	server.Players["blue"].X = 100
+	for i := 0; i < game.HillInterval; i++ {
+		snap = server.Tick()
+	}
+	if patched := game.PatchSnapshot(base, game.DiffSnapshots(base, snap)); len(patched.Teams) != 2 || patched.Teams[0] != 3 {
+		t.Errorf("Expected the delta to carry the new team points, got %v", patched.Teams)
+	}
+	if delta := game.DiffSnapshots(snap, snap); delta.Teams != nil {
+		t.Errorf("Expected unchanged team points to be left out of a delta, got %v", delta.Teams)
+	}
+}
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Turret dimensions, drawn over the hull sprite
+const (
+	TurretLength = 26
+	TurretWidth  = 6
+	TurretHub    = 14
+)
+
+var (
+	turretImage *ebiten.Image
+	hubImage    *ebiten.Image
+)
+
+// loadTurret builds the turret sprites
+func loadTurret() {
+	turretImage = ebiten.NewImage(TurretLength, TurretWidth)
+	turretImage.Fill(color.RGBA{40, 70, 40, 255})
+	hubImage = ebiten.NewImage(TurretHub, TurretHub)
+	hubImage.Fill(color.RGBA{60, 100, 60, 255})
+}
+
+// drawTurret renders a player's turret rotated independently of the hull
+func drawTurret(screen *ebiten.Image, player *Player, x, y float64) {
+	if turretImage == nil {
+		return
+	}
+
+	// Barrel pivots around its back end at the tank's center
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(0, -TurretWidth/2)
+	op.GeoM.Rotate(player.TurretAngle)
+	op.GeoM.Translate(x, y)
+	screen.DrawImage(turretImage, op)
+
+	op = &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-TurretHub/2, -TurretHub/2)
+	op.GeoM.Rotate(player.TurretAngle)
+	op.GeoM.Translate(x, y)
+	screen.DrawImage(hubImage, op)
+}
@@ -0,0 +1,195 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// SwitchDelay is how many frames a new weapon takes to become ready
+const SwitchDelay = 15
+
+// Weapon describes how a weapon fires and what its projectiles do
+type Weapon struct {
+	ID           string
+	Name         string
+	Damage       int     // Per projectile on a direct hit
+	Cooldown     int     // Frames between shots
+	Speed        float64 // Projectile speed per frame
+	Spread       float64 // Largest random angle a projectile strays from the aim (radians)
+	Pellets      int     // Projectiles per shot
+	Range        float64 // Distance a projectile flies before it fizzles, 0 for no limit
+	Magazine     int     // Shots before reloading, 0 for no reloads
+	Reload       int     // Frames to reload a magazine
+	Splash       float64 // Radius of the explosion where it lands, 0 for none
+	SplashDamage int     // Damage to everyone else inside the explosion
+	Bounces      int     // Times a projectile ricochets off the arena walls
+	Color        color.RGBA
+}
+
+// Weapon IDs
+const (
+	Blaster    = "blaster"
+	MachineGun = "machinegun"
+	Shotgun    = "shotgun"
+	Cannon     = "cannon"
+	Ricochet   = "ricochet"
+)
+
+// Weapons lists every weapon in switching order, the blaster is the
+// original gun every tank starts with
+var Weapons = []Weapon{
+	{ID: Blaster, Name: "Blaster", Damage: DamageAmount, Cooldown: ShotCooldown, Speed: BulletSpeed, Pellets: 1,
+		Color: color.RGBA{255, 255, 0, 255}},
+	{ID: MachineGun, Name: "Machine gun", Damage: 3, Cooldown: 5, Speed: 6, Spread: 0.06, Pellets: 1, Range: 500,
+		Magazine: 30, Reload: 120, Color: color.RGBA{255, 200, 120, 255}},
+	{ID: Shotgun, Name: "Shotgun", Damage: 4, Cooldown: 45, Speed: 5, Spread: 0.25, Pellets: 6, Range: 220,
+		Magazine: 4, Reload: 90, Color: color.RGBA{255, 140, 40, 255}},
+	{ID: Cannon, Name: "Cannon", Damage: 20, Cooldown: 70, Speed: 3, Pellets: 1, Range: 600,
+		Splash: 50, SplashDamage: 10, Color: color.RGBA{255, 60, 60, 255}},
+	{ID: Ricochet, Name: "Ricochet", Damage: 8, Cooldown: 30, Speed: 5, Pellets: 1, Range: 1200,
+		Bounces: 2, Color: color.RGBA{120, 220, 255, 255}},
+}
+
+// WeaponByID looks a weapon up, unknown or empty IDs give the blaster
+func WeaponByID(id string) Weapon {
+	for _, w := range Weapons {
+		if w.ID == id {
+			return w
+		}
+	}
+	return Weapons[0]
+}
+
+// ValidWeapon reports whether id names a weapon
+func ValidWeapon(id string) bool {
+	for _, w := range Weapons {
+		if w.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// NextWeapon returns the weapon after id in switching order
+func NextWeapon(id string) string {
+	for i, w := range Weapons {
+		if w.ID == id {
+			return Weapons[(i+1)%len(Weapons)].ID
+		}
+	}
+	return Weapons[1%len(Weapons)].ID
+}
+
+// switchWeapon arms a player with another weapon, with a full magazine
+// once the switch delay is over
+func switchWeapon(player *Player, id string) {
+	if id == "" || id == WeaponByID(player.Weapon).ID || !ValidWeapon(id) {
+		return
+	}
+	player.Weapon = id
+	player.shots = 0
+	player.reloading = 0
+	player.cooldown = SwitchDelay
+}
+
+// coolDown advances a player's weapon timers by one frame
+func coolDown(player *Player) {
+	if player.cooldown > 0 {
+		player.cooldown--
+	}
+	if player.reloading > 0 {
+		player.reloading--
+	}
+}
+
+// tryFire shoots the player's weapon if it is ready, counting shots
+// towards the next reload
+func (g *Game) tryFire(player *Player) {
+	if player.cooldown > 0 || player.reloading > 0 {
+		return
+	}
+	w := WeaponByID(player.Weapon)
+	g.fireBullet(player)
+	player.cooldown = w.Cooldown
+	if player.rapid > 0 {
+		player.cooldown /= 2
+	}
+
+	if w.Magazine > 0 {
+		player.shots++
+		if player.shots >= w.Magazine {
+			player.shots = 0
+			player.reloading = w.Reload
+		}
+	}
+}
+
+// splash damages everyone near where a projectile with splash landed,
+// except the player it hit directly (already damaged), its shooter and,
+// without friendly fire, the shooter's teammates.
+// Like direct hits, only the referee or the victim's own client decides.
+func (g *Game) splash(b Bullet, direct string) {
+	w := WeaponByID(b.Weapon)
+	if w.Splash == 0 {
+		return
+	}
+	// The grid is up to date, splash only happens while bullets move
+	for _, target := range g.grid.near(b.X, b.Y, b.X, b.Y, w.Splash, nil) {
+		pid := target.ID
+		if pid == direct || pid == b.OwnerID || target.eliminated || g.harmless(b.OwnerID, pid) {
+			continue
+		}
+		if math.Hypot(target.X-b.X, target.Y-b.Y) > w.Splash {
+			continue
+		}
+		if g.Authoritative || (g.ownsPlayer(pid) && !g.Client) {
+			g.confirmHit(target, b, w.SplashDamage, false)
+		}
+	}
+}
+
+// bounce reflects a projectile that left the arena back into it
+func (g *Game) bounce(b *Bullet) {
+	if b.X < 0 || b.X > ScreenWidth {
+		b.vx = -b.vx
+		b.X = clamp(b.X, 0, ScreenWidth)
+	}
+	if b.Y < 0 || b.Y > ScreenHeight {
+		b.vy = -b.vy
+		b.Y = clamp(b.Y, 0, ScreenHeight)
+	}
+	b.bounces++
+
+	// Snapshots describe the new straight segment
+	b.originX, b.originY = b.X, b.Y
+	b.spawnTick = g.tick
+}
+
+// spread returns a random angle within the weapon's spread
+func (g *Game) spread(w Weapon) float64 {
+	if w.Spread == 0 {
+		return 0
+	}
+	return (g.random().Float64()*2 - 1) * w.Spread
+}
+
+// drawHUD shows the local player's weapon and ammo
+func (g *Game) drawHUD(screen *ebiten.Image) {
+	player, exists := g.Players[g.LocalPlayerID]
+	if !exists || player.eliminated {
+		return
+	}
+	w := WeaponByID(player.Weapon)
+	status := "ready"
+	switch {
+	case player.reloading > 0:
+		status = "reloading"
+	case w.Magazine > 0:
+		status = fmt.Sprintf("%d/%d", w.Magazine-player.shots, w.Magazine)
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%s  %s", w.Name, status), 10, ScreenHeight-20)
+}
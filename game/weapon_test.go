@@ -0,0 +1,98 @@
+package game_test
+
+import (
+	"math"
+	"testing"
+
+	"shooter/game"
+)
+
+// ** Test Shotgun Pellets And Reload**
+func TestShotgunFiresPelletsAndReloads(t *testing.T) {
+	var sent []game.BulletMessage
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		LocalPlayerID: "player1",
+		SendUpdate: func(msg interface{}) {
+			if b, ok := msg.(game.BulletMessage); ok {
+				sent = append(sent, b)
+			}
+		},
+	}
+	gameInstance.Players["player1"] = &game.Player{ID: "player1", X: 300, Y: 300, Health: game.MaxHealth}
+	shotgun := game.WeaponByID(game.Shotgun)
+
+	// Switching takes a moment before the new weapon fires
+	gameInstance.ApplyLocalInput(game.PlayerInput{Weapon: game.Shotgun, Fire: true})
+	if len(sent) != 0 {
+		t.Fatalf("Expected no shot during the weapon switch")
+	}
+
+	// Hold fire long enough to empty the magazine and then some
+	frames := game.SwitchDelay + shotgun.Magazine*shotgun.Cooldown
+	for i := 0; i < frames; i++ {
+		gameInstance.ApplyLocalInput(game.PlayerInput{Fire: true})
+	}
+	if len(sent) != shotgun.Magazine*shotgun.Pellets {
+		t.Fatalf("Expected %d pellets before reloading, got %d", shotgun.Magazine*shotgun.Pellets, len(sent))
+	}
+	for _, b := range sent {
+		if b.Weapon != game.Shotgun {
+			t.Fatalf("Expected bullets to carry the shotgun weapon ID, got %q", b.Weapon)
+		}
+	}
+
+	// Still reloading
+	for i := 0; i < shotgun.Reload/3; i++ {
+		gameInstance.ApplyLocalInput(game.PlayerInput{Fire: true})
+	}
+	if len(sent) != shotgun.Magazine*shotgun.Pellets {
+		t.Errorf("Expected no shots while reloading, got %d bullets", len(sent))
+	}
+}
+
+// ** Test Cannon Splash Damage**
+func TestCannonSplashDamage(t *testing.T) {
+	gameInstance := &game.Game{
+		Players:       make(map[string]*game.Player),
+		Authoritative: true,
+	}
+	gameInstance.Players["shooter"] = &game.Player{ID: "shooter", X: 500, Y: 500, Health: game.MaxHealth}
+	gameInstance.Players["victim"] = &game.Player{ID: "victim", X: 100, Y: 100, Angle: math.Pi, Health: game.MaxHealth} // Facing the shot
+	gameInstance.Players["bystander"] = &game.Player{ID: "bystander", X: 100, Y: 140, Health: game.MaxHealth}
+	gameInstance.Players["faraway"] = &game.Player{ID: "faraway", X: 300, Y: 100, Health: game.MaxHealth}
+	gameInstance.AddBulletFromPeer(game.BulletMessage{ID: 1, OwnerID: "shooter", Weapon: game.Cannon, X: 98, Y: 105, VX: 3})
+
+	gameInstance.UpdateBullets()
+
+	cannon := game.WeaponByID(game.Cannon)
+	if health := gameInstance.Players["victim"].Health; health != game.MaxHealth-cannon.Damage {
+		t.Errorf("Expected a direct hit to deal %d, health is %d", cannon.Damage, health)
+	}
+	if health := gameInstance.Players["bystander"].Health; health != game.MaxHealth-cannon.SplashDamage {
+		t.Errorf("Expected splash to deal %d, health is %d", cannon.SplashDamage, health)
+	}
+	if health := gameInstance.Players["faraway"].Health; health != game.MaxHealth {
+		t.Errorf("Expected players outside the splash to be unharmed, health is %d", health)
+	}
+}
+
+// ** Test Ricochet**
+func TestRicochetBouncesOffWalls(t *testing.T) {
+	gameInstance := &game.Game{Players: make(map[string]*game.Player)}
+	gameInstance.AddBulletFromPeer(game.BulletMessage{ID: 1, OwnerID: "shooter", Weapon: game.Ricochet, X: game.ScreenWidth - 2, Y: 300, VX: 5})
+	gameInstance.AddBulletFromPeer(game.BulletMessage{ID: 2, OwnerID: "shooter", X: game.ScreenWidth - 2, Y: 200, VX: 5})
+
+	gameInstance.UpdateBullets()
+
+	ricochet, plain := gameInstance.Bullets[0], gameInstance.Bullets[1]
+	if !ricochet.Active {
+		t.Fatalf("Expected the ricochet shell to bounce")
+	}
+	if vx, _ := ricochet.Velocity(); vx >= 0 {
+		t.Errorf("Expected the shell to head back into the arena, got vx %f", vx)
+	}
+	if plain.Active {
+		t.Errorf("Expected a blaster bullet to leave the arena")
+	}
+}
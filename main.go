@@ -1,52 +1,150 @@
 package main
 
 import (
+	"flag"
 	"os"
 	"fmt"
 
+	"shooter/bots"
 	"shooter/game"
 	"shooter/peer"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-        fmt.Println("Usage: go run main.go <port>")
+	serverAddr := flag.String("server", "", "Connect to a dedicated server (host:port) instead of the peer mesh")
+	hostMode := flag.Bool("host", false, "Elect one peer as host to referee the match, migrating if it leaves")
+	var network peer.Conditions // Simulated network conditions for every connection
+	flag.DurationVar(&network.Latency, "latency", 0, "Simulated latency added to every message sent (e.g. 150ms)")
+	flag.DurationVar(&network.Jitter, "jitter", 0, "Simulated random extra latency, up to this much")
+	flag.Float64Var(&network.Loss, "loss", 0, "Simulated chance of dropping a message (0..1)")
+	flag.Float64Var(&network.Reorder, "reorder", 0, "Simulated chance of delivering a message out of order (0..1)")
+	flag.IntVar(&network.Bandwidth, "bandwidth", 0, "Simulated upload cap in bytes per second (0 for none)")
+	botCount := flag.Int("bots", 0, "Computer-controlled tanks to add to a mesh match")
+	botSkill := flag.String("difficulty", "normal", "Bot skill: easy, normal or hard")
+	matchFlags := game.RegisterMatchFlags(flag.CommandLine, 1)
+	team := flag.Int("team", 0, "Team to join from 1, 0 to be put on the smallest team")
+	spectate := flag.Bool("spectate", false, "Watch the match without a tank (Tab cycles the camera)")
+	recordPath := flag.String("record", "", "Record the match to a replay file")
+	replayPath := flag.String("replay", "", "Play back a replay file instead of joining a match")
+	controlsPath := flag.String("controls", "controls.json", "Key, mouse and gamepad bindings file (F1 in game to rebind)")
+	hitboxes := flag.Bool("hitboxes", false, "Outline tank hitboxes and bullet sweeps (F3 in game toggles)")
+	addrFlags := peer.AddrFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *replayPath != "" {
+		if err := game.RunReplay(*replayPath); err != nil {
+			fmt.Println("Error playing replay:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.NArg() < 1 {
+        fmt.Println("Usage: go run main.go [flags] <port>  (or: go run main.go -replay file)")
         os.Exit(1)
     }
 
-    port := os.Args[1]  // Take port from CLI arguments
+    port := flag.Arg(0)  // Take port from CLI arguments
 
-    playerAddr := fmt.Sprintf("192.168.0.100:%s", port) // Update with actual LAN IP
-	peer.SelfAddr = playerAddr // Store self address in peer package
+	match, err := matchFlags.Config()
+	if err != nil {
+		fmt.Println("Invalid match settings:", err)
+		os.Exit(1)
+	}
+	if match.NeedsReferee() && *serverAddr == "" && !*hostMode {
+		fmt.Println("Invalid match settings: -mode", match.Mode, "needs -server or -host to keep score")
+		os.Exit(1)
+	}
 
-	// Handle player exit properly
-	peer.HandleExit()
+	controls, err := game.LoadControls(*controlsPath)
+	if err != nil {
+		fmt.Println("Error loading controls:", err)
+		os.Exit(1)
+	}
+
+	addrs, err := addrFlags.Resolve(port)
+	if err != nil {
+		fmt.Println("Error finding this machine's LAN address (set -advertise and -discovery):", err)
+		os.Exit(1)
+	}
+	playerAddr := addrs.Advertise
 
-	// Register player with the discovery server
-	peer.RegisterWithDiscovery(playerAddr)
+	// The node carries the game's messages to and from other peers
+	node := peer.NewNode(append(addrs.Options(), peer.WithConditions(network))...)
 
 	// Create the game instance
     gameInstance := &game.Game{
 		LocalPlayerID: playerAddr,
         Players: make(map[string]*game.Player),
-        ActiveConnections: peer.ActiveConnections,
-		SendUpdate: peer.SendUpdate, // Inject function
-
+		SendUpdate: node.Broadcast, // Inject function
+		SendTo: node.SendTo,
+		Client: *serverAddr != "",
+		HostMode: *hostMode && *serverAddr == "",
+		Spectator: *spectate,
+		Match: match,
+		Team: *team,
+		Controls: controls,
+		ControlsPath: *controlsPath,
+		ShowHitboxes: *hitboxes,
     }
-	// Set game instance in peer package
-	peer.GameInstance = gameInstance
+	// Messages from peers go to the game
+	node.SetHandler(gameInstance)
 
-	// Start TCP server to accept peer connections
-	go peer.StartPeerServer(playerAddr)
-    
-	// Get discovered peers and connect to them
-	peerList := peer.GetPeers()
-	for _, peerAddr := range peerList {
-		if peerAddr != playerAddr {
-			go peer.ConnectToPeer(peerAddr)
+	// Bots owned by this client share the mesh like we do; with a server or
+	// host, run them there or as cmd/bot peers instead
+	if *botCount > 0 {
+		skill, ok := bots.ParseDifficulty(*botSkill)
+		if !ok || *serverAddr != "" || *hostMode {
+			fmt.Println("Bots need the plain mesh and a difficulty of easy, normal or hard")
+			os.Exit(1)
+		}
+		for i := 1; i <= *botCount; i++ {
+			gameInstance.AddBot(fmt.Sprintf("%s/bot-%d", playerAddr, i), bots.New(skill, 0))
+		}
+	}
+
+	if *recordPath != "" {
+		recorder, err := game.NewRecorder(*recordPath, playerAddr)
+		if err != nil {
+			fmt.Println("Error creating replay file:", err)
+			os.Exit(1)
+		}
+		defer recorder.Close()
+		gameInstance.Recorder = recorder
+	}
+
+	// Handle player exit properly
+	node.HandleExit()
+
+	if *serverAddr != "" {
+		// The server is the only connection, it relays everything
+		if err := node.Connect(*serverAddr); err != nil {
+			os.Exit(1)
 		}
+		if *spectate {
+			node.Broadcast(game.SpectateMessage{Type: "spectate", ID: playerAddr})
+		} else {
+			node.Broadcast(game.JoinMessage{Type: "join", ID: playerAddr, Team: *team})
+		}
+		gameInstance.MainGame(gameInstance)
+		return
+	}
+
+	// Start TCP server to accept peer connections. Spectators listen too,
+	// so players who join later find them and send them their updates.
+	if err := node.Start(); err != nil {
+		fmt.Println("Error starting peer server:", err)
+		os.Exit(1)
+	}
+
+	// Register player so other peers discover it
+	node.Register()
+
+	// Get discovered peers and connect to them
+	for _, peerAddr := range node.Peers() {
+		go node.Connect(peerAddr)
 	}
 
 	// Start the game
 	gameInstance.MainGame(gameInstance)
-}
\ No newline at end of file
+}
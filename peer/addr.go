@@ -0,0 +1,147 @@
+package peer
+
+import (
+	"errors"
+	"flag"
+	"net"
+	"os"
+)
+
+// Environment variables the address flags default to
+const (
+	ListenEnv    = "SHOOTER_LISTEN"
+	AdvertiseEnv = "SHOOTER_ADVERTISE"
+	DiscoveryEnv = "SHOOTER_DISCOVERY"
+	LANEnv       = "SHOOTER_LAN"
+)
+
+// DiscoveryPort is the port the discovery server listens on
+const DiscoveryPort = "5000"
+
+// Addrs says where a node listens, the address it tells other peers (also
+// its player ID) and where its discovery server is, unless it finds peers
+// on the LAN. Empty fields are filled in by Resolve.
+type Addrs struct {
+	Listen    string
+	Advertise string
+	Discovery string
+	LAN       bool // Multicast discovery on the local network instead of a server
+}
+
+// AddrFlags registers -listen, -advertise, -discovery and -lan on fs, each
+// defaulting to its environment variable
+func AddrFlags(fs *flag.FlagSet) *Addrs {
+	a := &Addrs{}
+	fs.StringVar(&a.Listen, "listen", os.Getenv(ListenEnv), "Address to accept peers on, default every interface at <port> (env "+ListenEnv+")")
+	fs.StringVar(&a.Advertise, "advertise", os.Getenv(AdvertiseEnv), "Address other peers reach us at, a host alone keeps the listen port; default this machine's LAN IP (env "+AdvertiseEnv+")")
+	fs.StringVar(&a.Discovery, "discovery", os.Getenv(DiscoveryEnv), "Discovery server address, default port "+DiscoveryPort+" at this machine's LAN IP (env "+DiscoveryEnv+")")
+	fs.BoolVar(&a.LAN, "lan", os.Getenv(LANEnv) != "", "Find peers on the local network by multicast, no discovery server needed (env "+LANEnv+")")
+	return a
+}
+
+// Options configures a node with these addresses and discovery
+func (a Addrs) Options() []Option {
+	discovery := WithDiscovery(a.Discovery)
+	if a.LAN {
+		discovery = WithDiscoveryBackend(NewLANDiscovery("", ""))
+	}
+	return []Option{WithListenAddr(a.Listen), WithSelfAddr(a.Advertise), discovery}
+}
+
+// Resolve fills in what wasn't given for a node on port: it listens on
+// every interface, and advertises and looks for discovery at the LAN IP
+// from LocalIP
+func (a Addrs) Resolve(port string) (Addrs, error) {
+	if a.Listen == "" {
+		a.Listen = ":" + port
+	}
+	listenHost, listenPort, err := net.SplitHostPort(a.Listen)
+	if err != nil {
+		return a, err
+	}
+
+	// A lazily found LAN IP, only when something needs it
+	var lanIP string
+	local := func() (string, error) {
+		if lanIP == "" {
+			ip, err := LocalIP()
+			if err != nil {
+				return "", err
+			}
+			lanIP = ip.String()
+		}
+		return lanIP, nil
+	}
+
+	switch {
+	case a.Advertise == "":
+		// Listening on one address means that is where we are reached
+		host := listenHost
+		if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+			if host, err = local(); err != nil {
+				return a, err
+			}
+		}
+		a.Advertise = net.JoinHostPort(host, listenPort)
+	case !hasPort(a.Advertise):
+		a.Advertise = net.JoinHostPort(a.Advertise, listenPort)
+	}
+
+	switch {
+	case a.LAN:
+		// No server to find
+	case a.Discovery == "":
+		host, err := local()
+		if err != nil {
+			return a, err
+		}
+		a.Discovery = net.JoinHostPort(host, DiscoveryPort)
+	case !hasPort(a.Discovery):
+		a.Discovery = net.JoinHostPort(a.Discovery, DiscoveryPort)
+	}
+	return a, nil
+}
+
+// hasPort reports whether addr is host:port rather than a bare host
+func hasPort(addr string) bool {
+	_, _, err := net.SplitHostPort(addr)
+	return err == nil
+}
+
+// LocalIP returns this machine's primary non-loopback IPv4 address: the
+// one its default route goes out of, or else the first on an interface
+// that is up
+func LocalIP() (net.IP, error) {
+	// Dialing UDP sends nothing, it only asks which address would be used
+	if conn, err := net.Dial("udp4", "8.8.8.8:80"); err == nil {
+		defer conn.Close()
+		if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && !addr.IP.IsLoopback() && !addr.IP.IsUnspecified() {
+			return addr.IP, nil
+		}
+	}
+
+	// No default route, e.g. a LAN without internet
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ip := ipNet.IP.To4(); ip != nil && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() {
+				return ip, nil
+			}
+		}
+	}
+	return nil, errors.New("peer: no non-loopback network interface found")
+}
@@ -0,0 +1,62 @@
+package peer_test
+
+import (
+	"flag"
+	"testing"
+
+	"shooter/peer"
+)
+
+// ** Test Resolving Given Addresses**
+func TestResolveAddrs(t *testing.T) {
+	tests := []struct {
+		given peer.Addrs
+		port  string
+		want  peer.Addrs
+	}{
+		// Everything given, only the port is taken from the listen address
+		{
+			given: peer.Addrs{Advertise: "10.0.0.5", Discovery: "10.0.0.1"},
+			port:  "8080",
+			want:  peer.Addrs{Listen: ":8080", Advertise: "10.0.0.5:8080", Discovery: "10.0.0.1:" + peer.DiscoveryPort},
+		},
+		// Listening on one address advertises it
+		{
+			given: peer.Addrs{Listen: "10.0.0.7:9000", Discovery: "10.0.0.1:5001"},
+			port:  "8080",
+			want:  peer.Addrs{Listen: "10.0.0.7:9000", Advertise: "10.0.0.7:9000", Discovery: "10.0.0.1:5001"},
+		},
+	}
+	for _, test := range tests {
+		got, err := test.given.Resolve(test.port)
+		if err != nil {
+			t.Fatalf("Resolve(%+v): %v", test.given, err)
+		}
+		if got != test.want {
+			t.Errorf("Resolve(%+v) = %+v, expected %+v", test.given, got, test.want)
+		}
+	}
+
+	if _, err := (peer.Addrs{Listen: "no-port"}).Resolve("8080"); err == nil {
+		t.Errorf("Expected an error for a listen address without a port")
+	}
+}
+
+// ** Test Address Flags Default To The Environment**
+func TestAddrFlagsFromEnv(t *testing.T) {
+	t.Setenv(peer.AdvertiseEnv, "10.0.0.5:8080")
+	t.Setenv(peer.DiscoveryEnv, "10.0.0.1:5000")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	addrs := peer.AddrFlags(fs)
+	if err := fs.Parse([]string{"-discovery", "10.0.0.2:5000"}); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+
+	if addrs.Advertise != "10.0.0.5:8080" {
+		t.Errorf("Expected the advertised address from the environment, got %q", addrs.Advertise)
+	}
+	if addrs.Discovery != "10.0.0.2:5000" {
+		t.Errorf("Expected the flag to override the environment, got %q", addrs.Discovery)
+	}
+}
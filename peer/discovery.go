@@ -0,0 +1,71 @@
+package peer
+
+import (
+	"encoding/json"
+	"net"
+)
+
+// Discovery is how a node announces itself and finds the other peers of a
+// match: a discovery server (NewServerDiscovery) or the local network
+// (NewLANDiscovery)
+type Discovery interface {
+	Register(self string) error   // Let other peers find self
+	Deregister(self string) error // Stop announcing self, e.g. on exit
+	Peers() ([]string, error)     // Everyone registered, possibly self too
+}
+
+// Request structure for discovery server
+type Request struct {
+	Type string `json:"type"`
+	Addr string `json:"addr,omitempty"`
+}
+
+// Response structure from discovery server
+type Response struct {
+	Peers []string `json:"peers"`
+}
+
+// serverDiscovery registers with a discovery server (discovery_server) over TCP
+type serverDiscovery struct {
+	addr string
+}
+
+// NewServerDiscovery finds peers through the discovery server at addr
+func NewServerDiscovery(addr string) Discovery {
+	return serverDiscovery{addr: addr}
+}
+
+func (d serverDiscovery) Register(self string) error {
+	return d.send(Request{Type: "register", Addr: self})
+}
+
+func (d serverDiscovery) Deregister(self string) error {
+	return d.send(Request{Type: "deregister", Addr: self})
+}
+
+// send sends a request that needs no answer
+func (d serverDiscovery) send(req Request) error {
+	conn, err := net.Dial("tcp", d.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return json.NewEncoder(conn).Encode(req)
+}
+
+func (d serverDiscovery) Peers() ([]string, error) {
+	conn, err := net.Dial("tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(Request{Type: "get_peers"}); err != nil {
+		return nil, err
+	}
+	var res Response
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res.Peers, nil
+}
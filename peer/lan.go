@@ -0,0 +1,141 @@
+package peer
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LANGroup is the multicast group and port LAN discovery talks on, in the
+// range reserved for the local site
+const LANGroup = "239.255.77.77:5001"
+
+// LANWait is how long Peers listens for answers from the local network
+const LANWait = 500 * time.Millisecond
+
+// lanMessage is what LAN discovery sends: a "query" to the group asks every
+// registered peer of the same game to answer with an "announce"
+type lanMessage struct {
+	Type string `json:"type"`
+	Game string `json:"game,omitempty"`
+	Addr string `json:"addr,omitempty"`
+}
+
+// LANDiscovery finds peers on the local subnet by UDP multicast, without
+// any server. Registered peers listen on the group and answer queries;
+// several can share a machine.
+type LANDiscovery struct {
+	group string // Multicast group:port
+	game  string // Only peers of the same game answer each other
+	wait  time.Duration
+
+	mutex sync.Mutex
+	conn  *net.UDPConn // Answers queries while registered
+}
+
+// NewLANDiscovery finds the peers of game on the local network, through
+// the multicast group (LANGroup when empty)
+func NewLANDiscovery(group, game string) *LANDiscovery {
+	if group == "" {
+		group = LANGroup
+	}
+	return &LANDiscovery{group: group, game: game, wait: LANWait}
+}
+
+// Register answers queries with self until Deregister
+func (d *LANDiscovery) Register(self string) error {
+	groupAddr, err := net.ResolveUDPAddr("udp4", d.group)
+	if err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.conn != nil {
+		return errors.New("peer: already registered on the LAN")
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, groupAddr)
+	if err != nil {
+		return err
+	}
+	d.conn = conn
+	go d.answer(conn, self)
+	return nil
+}
+
+// answer replies to every query for our game until the connection closes
+func (d *LANDiscovery) answer(conn *net.UDPConn, self string) {
+	reply, _ := json.Marshal(lanMessage{Type: "announce", Game: d.game, Addr: self})
+	buf := make([]byte, 1024)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		var msg lanMessage
+		if json.Unmarshal(buf[:n], &msg) != nil || msg.Type != "query" || msg.Game != d.game {
+			continue
+		}
+		conn.WriteToUDP(reply, from)
+	}
+}
+
+// Deregister stops answering queries
+func (d *LANDiscovery) Deregister(self string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.conn == nil {
+		return nil
+	}
+	err := d.conn.Close()
+	d.conn = nil
+	return err
+}
+
+// Peers asks the group who is playing and collects the answers that
+// arrive within LANWait, sorted
+func (d *LANDiscovery) Peers() ([]string, error) {
+	groupAddr, err := net.ResolveUDPAddr("udp4", d.group)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	query, _ := json.Marshal(lanMessage{Type: "query", Game: d.game})
+	if _, err := conn.WriteToUDP(query, groupAddr); err != nil {
+		return nil, err
+	}
+
+	// Answers come straight back to our socket until the wait is over
+	conn.SetReadDeadline(time.Now().Add(d.wait))
+	seen := map[string]bool{}
+	buf := make([]byte, 1024)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+		var msg lanMessage
+		if json.Unmarshal(buf[:n], &msg) != nil || msg.Type != "announce" || msg.Game != d.game || msg.Addr == "" {
+			continue
+		}
+		seen[msg.Addr] = true
+	}
+
+	peers := make([]string, 0, len(seen))
+	for addr := range seen {
+		peers = append(peers, addr)
+	}
+	sort.Strings(peers)
+	return peers, nil
+}
@@ -0,0 +1,43 @@
+package peer_test
+
+import (
+	"testing"
+
+	"shooter/peer"
+)
+
+// ** Test LAN Discovery**
+func TestLANDiscovery(t *testing.T) {
+	const group = "239.255.77.77:15001" // Away from the real group
+	host := peer.NewLANDiscovery(group, "test")
+	if err := host.Register("10.0.0.5:8080"); err != nil {
+		t.Skipf("No multicast here: %v", err)
+	}
+	defer host.Deregister("10.0.0.5:8080")
+
+	// Peers on one machine share the group's port
+	other := peer.NewLANDiscovery(group, "test")
+	if err := other.Register("10.0.0.6:8080"); err != nil {
+		t.Fatalf("Failed to register a second peer: %v", err)
+	}
+
+	peers, err := peer.NewLANDiscovery(group, "test").Peers()
+	if err != nil {
+		t.Skipf("No multicast here: %v", err)
+	}
+	if len(peers) != 2 || peers[0] != "10.0.0.5:8080" || peers[1] != "10.0.0.6:8080" {
+		t.Errorf("Expected to find both registered peers, got %v", peers)
+	}
+
+	// Another game on the same network stays out of it
+	if peers, _ := peer.NewLANDiscovery(group, "other").Peers(); len(peers) != 0 {
+		t.Errorf("Expected no peers from another game, got %v", peers)
+	}
+
+	// Deregistered peers stop answering
+	host.Deregister("10.0.0.5:8080")
+	other.Deregister("10.0.0.6:8080")
+	if peers, _ := peer.NewLANDiscovery(group, "test").Peers(); len(peers) != 0 {
+		t.Errorf("Expected no peers after deregistering, got %v", peers)
+	}
+}
@@ -0,0 +1,180 @@
+package peer
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// Conditions describes a simulated network link. They shape what this
+// peer writes, so two peers both using 150ms latency see a 300ms round trip.
+// Each Write is treated as one packet: game messages are written whole, so
+// dropping or reordering a write never splits a message.
+type Conditions struct {
+	Latency   time.Duration // Delay added to every message
+	Jitter    time.Duration // Random extra delay, up to this much
+	Loss      float64       // Chance a message is dropped (0..1)
+	Reorder   float64       // Chance a message is held back so later ones overtake it
+	Bandwidth int           // Bytes per second, 0 for unlimited
+	Seed      int64         // Random seed, 0 picks one from the clock
+}
+
+// Enabled reports whether the conditions change anything
+func (c Conditions) Enabled() bool {
+	return c.Latency > 0 || c.Jitter > 0 || c.Loss > 0 || c.Reorder > 0 || c.Bandwidth > 0
+}
+
+// SimQueue is how many packets a simulated link holds before it drops
+// more, like a full router buffer
+const SimQueue = 1024
+
+// packet is a write waiting to be delivered
+type packet struct {
+	at   time.Time
+	data []byte
+}
+
+// simConn wraps a net.Conn and applies Conditions to everything written
+type simConn struct {
+	net.Conn
+	cond  Conditions
+	queue chan packet
+
+	mutex     sync.Mutex // Guards the fields below
+	rng       *rand.Rand
+	lastAt    time.Time // Delivery time of the last in-order packet
+	linkFree  time.Time // When the simulated link finishes sending
+	closed    bool
+	dropped   int        // Packets lost to Loss or a full queue
+	writeLock sync.Mutex // Keeps delivered packets from interleaving
+
+	errLock sync.Mutex
+	err     error // First error from the real connection
+}
+
+// WrapConn returns conn with the given network conditions applied to its
+// writes, or conn itself if the conditions are all zero
+func WrapConn(conn net.Conn, cond Conditions) net.Conn {
+	if !cond.Enabled() {
+		return conn
+	}
+	seed := cond.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	c := &simConn{
+		Conn:  conn,
+		cond:  cond,
+		queue: make(chan packet, SimQueue),
+		rng:   rand.New(rand.NewSource(seed)),
+	}
+	go c.deliver()
+	return c
+}
+
+func (c *simConn) Write(p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.failure(); err != nil {
+		return 0, err
+	}
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	if c.rng.Float64() < c.cond.Loss {
+		c.dropped++
+		return len(p), nil // Lost on the way
+	}
+
+	// Bandwidth: packets queue up behind each other on the link
+	now := time.Now()
+	sendAt := now
+	if c.linkFree.After(sendAt) {
+		sendAt = c.linkFree
+	}
+	if c.cond.Bandwidth > 0 {
+		sendAt = sendAt.Add(time.Duration(len(p)) * time.Second / time.Duration(c.cond.Bandwidth))
+	}
+	c.linkFree = sendAt
+
+	at := sendAt.Add(c.cond.Latency)
+	if c.cond.Jitter > 0 {
+		at = at.Add(time.Duration(c.rng.Int63n(int64(c.cond.Jitter) + 1)))
+	}
+
+	data := append([]byte(nil), p...)
+
+	// A reordered packet is held back and delivered on its own
+	if c.rng.Float64() < c.cond.Reorder {
+		hold := c.cond.Latency + c.cond.Jitter + 20*time.Millisecond
+		time.AfterFunc(time.Until(at.Add(hold)), func() { c.send(data) })
+		return len(p), nil
+	}
+
+	// Jitter alone never reorders, like a real stream
+	if at.Before(c.lastAt) {
+		at = c.lastAt
+	}
+	c.lastAt = at
+
+	// Never wait for room while holding the lock, Close needs it
+	select {
+	case c.queue <- packet{at: at, data: data}:
+	default:
+		c.dropped++
+	}
+	return len(p), nil
+}
+
+// Dropped returns how many writes a conn from WrapConn lost to its
+// conditions or a full queue, 0 for any other conn
+func Dropped(conn net.Conn) int {
+	c, ok := conn.(*simConn)
+	if !ok {
+		return 0
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.dropped
+}
+
+// deliver writes queued packets to the real connection when they are due
+func (c *simConn) deliver() {
+	for pkt := range c.queue {
+		time.Sleep(time.Until(pkt.at))
+		c.send(pkt.data)
+	}
+}
+
+func (c *simConn) send(data []byte) {
+	c.writeLock.Lock()
+	_, err := c.Conn.Write(data)
+	c.writeLock.Unlock()
+
+	if err != nil {
+		c.errLock.Lock()
+		if c.err == nil {
+			c.err = err
+		}
+		c.errLock.Unlock()
+	}
+}
+
+// failure returns the first error the real connection reported
+func (c *simConn) failure() error {
+	c.errLock.Lock()
+	defer c.errLock.Unlock()
+	return c.err
+}
+
+func (c *simConn) Close() error {
+	c.mutex.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.queue)
+	}
+	c.mutex.Unlock()
+	return c.Conn.Close()
+}
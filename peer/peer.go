@@ -2,247 +2,415 @@ package peer
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"log"
 	"net"
 	"os"
-	"sync"
-	"strings"
 	"os/signal"
+	"sort"
+	"sync"
 	"syscall"
 	"time"
-
-	"shooter/game"
 )
 
-// Request structure for discovery server
-type Request struct {
-	Type string `json:"type"`
-	Addr string `json:"addr,omitempty"`
+// Handler receives what a node hears from its peers. *game.Game is one:
+// both methods only queue, so they are safe on the node's goroutines.
+type Handler interface {
+	Deliver(data []byte)  // A message from a peer
+	Disconnect(id string) // A peer (or a player it spoke for) went away
 }
 
-// Response structure from discovery server
-type Response struct {
-	Peers []string `json:"peers"`
+// Node is one peer of the mesh: it accepts and opens TCP connections to
+// other peers, hands what they send to its Handler and finds them through
+// its Discovery. Nodes share nothing, several can run in one process.
+type Node struct {
+	listenAddr string    // Where to accept peers, "" to only dial out
+	selfAddr   string    // How other peers reach us, registered with discovery
+	discovery  Discovery // How peers find each other, may be nil
+	handler    Handler   // May be nil
+	logger     *log.Logger
+	network    Conditions // Simulated network conditions for every connection
+
+	mutex      sync.Mutex
+	conns      map[string]net.Conn // Remote address -> connection
+	ids        map[string]net.Conn // Player ID -> connection it talks on
+	listener   net.Listener
+	closed     bool
+	registered bool // Announced to discovery, so there is something to deregister
 }
 
-var (
-	DiscoveryServer = "192.168.0.100:5000" // Replace with actual local IP
-	ActiveConnections = make(map[string]net.Conn) // Track connected peers
-	Mutex            = &sync.Mutex{}
-	SelfAddr         string // Store this peer's address
-	GameInstance *game.Game // Reference to game instance (main.go)
+// Option configures a Node, see NewNode
+type Option func(*Node)
 
-)
+// WithListenAddr sets the address to accept peer connections on, e.g. ":8080"
+func WithListenAddr(addr string) Option {
+	return func(n *Node) { n.listenAddr = addr }
+}
 
-//  Register this peer with the discovery server
-func RegisterWithDiscovery(addr string) {
-	conn, err := net.Dial("tcp", DiscoveryServer)
-	if err != nil {
-		fmt.Println("Error connecting to discovery server:", err)
-		return
-	}
-	defer conn.Close()
+// WithSelfAddr sets the address other peers reach this node at, the one
+// registered with the discovery server. Without it Start uses the address
+// it listens on.
+func WithSelfAddr(addr string) Option {
+	return func(n *Node) { n.selfAddr = addr }
+}
+
+// WithDiscovery finds peers through the discovery server at addr
+func WithDiscovery(addr string) Option {
+	return WithDiscoveryBackend(NewServerDiscovery(addr))
+}
+
+// WithDiscoveryBackend finds peers through d, e.g. NewLANDiscovery
+func WithDiscoveryBackend(d Discovery) Option {
+	return func(n *Node) { n.discovery = d }
+}
+
+// WithHandler sets who receives messages and disconnects
+func WithHandler(h Handler) Option {
+	return func(n *Node) { n.handler = h }
+}
+
+// WithLogger sets where the node logs connections and errors
+func WithLogger(l *log.Logger) Option {
+	return func(n *Node) { n.logger = l }
+}
+
+// WithConditions simulates network conditions on everything the node sends
+func WithConditions(c Conditions) Option {
+	return func(n *Node) { n.network = c }
+}
 
-	req := Request{Type: "register", Addr: addr}
-	json.NewEncoder(conn).Encode(req)
+// NewNode returns a node configured by opts. It does nothing on the
+// network until Start or Connect.
+func NewNode(opts ...Option) *Node {
+	n := &Node{
+		logger: log.New(os.Stdout, "", 0),
+		conns:  make(map[string]net.Conn),
+		ids:    make(map[string]net.Conn),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
 
-	fmt.Println("Registered with discovery server as:", addr)
+// SetHandler sets who receives messages, for handlers that need the node
+// to be built first (a game sends through it)
+func (n *Node) SetHandler(h Handler) {
+	n.mutex.Lock()
+	n.handler = h
+	n.mutex.Unlock()
 }
 
-// **Send deregistration request when exiting**
-func DeregisterFromDiscovery() {
-	conn, err := net.Dial("tcp", DiscoveryServer)
+// Start listens for peer connections on the listen address
+func (n *Node) Start() error {
+	if n.listenAddr == "" {
+		return errors.New("peer: no listen address")
+	}
+	listener, err := net.Listen("tcp", n.listenAddr)
 	if err != nil {
-		fmt.Println("Error connecting to discovery server:", err)
-		return
+		return err
 	}
-	defer conn.Close()
 
-	req := Request{Type: "deregister", Addr: SelfAddr}
-	json.NewEncoder(conn).Encode(req)
+	n.mutex.Lock()
+	if n.closed {
+		n.mutex.Unlock()
+		listener.Close()
+		return net.ErrClosed
+	}
+	n.listener = listener
+	if n.selfAddr == "" {
+		n.selfAddr = listener.Addr().String()
+	}
+	n.mutex.Unlock()
 
-	fmt.Println("Deregistered from discovery server:", SelfAddr)
+	n.logger.Println("Listening for peer connections on", listener.Addr())
+	go n.accept(listener)
+	return nil
 }
 
-// Handle SIGINT (CTRL+C) to clean up before exit
-func HandleExit() {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		fmt.Println("Shutting down...")
-
-		// Notify the discovery server
-		DeregisterFromDiscovery()
+// Addr returns the address the node listens on, nil before Start
+func (n *Node) Addr() net.Addr {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.listener == nil {
+		return nil
+	}
+	return n.listener.Addr()
+}
 
-		// Close all active connections
-		Mutex.Lock()
-		for _, conn := range ActiveConnections {
-			conn.Close()
+// accept takes peer connections until the listener closes
+func (n *Node) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			n.logger.Println("Error accepting peer connection:", err)
+			continue
 		}
-		ActiveConnections = make(map[string]net.Conn) // Clear connections
-		Mutex.Unlock()
 
-		os.Exit(0)
-	}()
+		peerAddr := conn.RemoteAddr().String()
+		if !n.add(peerAddr, WrapConn(conn, n.network)) {
+			continue
+		}
+		n.logger.Println("Accepted connection from:", peerAddr)
+	}
 }
 
-//  Get the list of peers from the discovery server
-func GetPeers() []string {
-    for retries := 0; retries < 3; retries++ {
-        conn, err := net.Dial("tcp", DiscoveryServer)
-        if err != nil {
-            fmt.Println("Error connecting to discovery server (retrying)...", err)
-            time.Sleep(2 * time.Second)
-            continue
-        }
-        defer conn.Close()
+// Connect opens a connection to a peer, unless we already have one
+func (n *Node) Connect(peerAddr string) error {
+	if n.Connected(peerAddr) {
+		return nil // Prevent duplicate connections
+	}
 
-        req := Request{Type: "get_peers"}
-        json.NewEncoder(conn).Encode(req)
+	conn, err := net.Dial("tcp", peerAddr)
+	if err != nil {
+		n.logger.Println("Error connecting to peer:", peerAddr, err)
+		return err
+	}
+	if !n.add(peerAddr, WrapConn(conn, n.network)) {
+		return nil
+	}
+	n.logger.Println("Connected to peer:", peerAddr)
+	return nil
+}
 
-        var res Response
-        json.NewDecoder(conn).Decode(&res)
+// add starts reading a new connection, it reports false and closes the
+// connection if we already have one to that address or are closed
+func (n *Node) add(peerAddr string, conn net.Conn) bool {
+	n.mutex.Lock()
+	if _, exists := n.conns[peerAddr]; exists || n.closed {
+		n.mutex.Unlock()
+		conn.Close()
+		return false
+	}
+	n.conns[peerAddr] = conn
+	n.mutex.Unlock()
 
-        // Filter out self address
-		filteredPeers := []string{}
-        for _, peer := range res.Peers {
-            if peer != SelfAddr {
-                filteredPeers = append(filteredPeers, peer)
-            }
-        }
+	go n.read(peerAddr, conn)
+	return true
+}
 
-        return filteredPeers // Success case
-    }
-    return nil // Return empty after 3 failed attempts
+// Connected reports whether the node has a connection to a peer address
+func (n *Node) Connected(peerAddr string) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	_, exists := n.conns[peerAddr]
+	return exists
 }
 
-//  Start TCP server to listen for peer connections
-func StartPeerServer(addr string) {
-	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%s", strings.Split(addr, ":")[1]))
-	if err != nil {
-		fmt.Println("Error starting peer server:", err)
-		return
+// Connections returns the addresses of every connected peer, sorted
+func (n *Node) Connections() []string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	addrs := make([]string, 0, len(n.conns))
+	for addr := range n.conns {
+		addrs = append(addrs, addr)
 	}
-	defer listener.Close()
+	sort.Strings(addrs)
+	return addrs
+}
 
-	fmt.Println("Listening for peer connections on", addr)
+// read hands everything a peer sends to the handler until it disconnects
+func (n *Node) read(peerAddr string, conn net.Conn) {
+	defer conn.Close()
 
+	// Messages are a stream of JSON values, a single read may hold several
+	// of them (or only part of a large snapshot)
+	decoder := json.NewDecoder(conn)
 	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			fmt.Println("Error accepting peer connection:", err)
-			continue
+		var message json.RawMessage
+		if err := decoder.Decode(&message); err != nil {
+			n.logger.Println("Peer disconnected:", peerAddr)
+			n.drop(peerAddr, conn)
+			return
 		}
 
-		peerAddr := conn.RemoteAddr().String()
+		n.rememberPeerID(conn, message)
 
-		Mutex.Lock()
-		if _, exists := ActiveConnections[peerAddr]; exists {
-			Mutex.Unlock()
-			conn.Close() // Close duplicate connection
-			continue
+		if h := n.currentHandler(); h != nil {
+			h.Deliver(message)
 		}
-		ActiveConnections[peerAddr] = conn
-		Mutex.Unlock()
+	}
+}
+
+// drop forgets a closed connection and tells the handler who went with it
+func (n *Node) drop(peerAddr string, conn net.Conn) {
+	n.mutex.Lock()
+	if n.conns[peerAddr] == conn {
+		delete(n.conns, peerAddr)
+	}
+	gone := []string{peerAddr}
+	for id, c := range n.ids {
+		if c == conn {
+			delete(n.ids, id)
+			if id != peerAddr {
+				gone = append(gone, id)
+			}
+		}
+	}
+	h := n.handler
+	n.mutex.Unlock()
+
+	if h != nil {
+		for _, id := range gone {
+			h.Disconnect(id)
+		}
+	}
+}
 
-		fmt.Println("Accepted connection from:", peerAddr)
+// currentHandler returns the handler, which SetHandler may change
+func (n *Node) currentHandler() Handler {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.handler
+}
 
-		go handlePeerCommunication(conn)
+// rememberPeerID maps the player ID a peer speaks for to its connection,
+// so SendTo can reach that player directly
+func (n *Node) rememberPeerID(conn net.Conn, message []byte) {
+	var envelope struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(message, &envelope) != nil || envelope.ID == "" {
+		return
 	}
+
+	n.mutex.Lock()
+	n.ids[envelope.ID] = conn
+	n.mutex.Unlock()
 }
 
-//  Connect to a discovered peer
-func ConnectToPeer(peerAddr string) {
-	Mutex.Lock()
-	if _, exists := ActiveConnections[peerAddr]; exists {
-		Mutex.Unlock()
-		return //  Prevent duplicate connections
+// Broadcast sends an update to every connected peer
+func (n *Node) Broadcast(data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		n.logger.Println("Error encoding update:", err)
+		return
 	}
-	Mutex.Unlock()
 
-	conn, err := net.Dial("tcp", peerAddr)
+	// Write without the lock, so one slow link can't stall the node
+	n.mutex.Lock()
+	conns := make([]net.Conn, 0, len(n.conns))
+	for _, conn := range n.conns {
+		conns = append(conns, conn)
+	}
+	n.mutex.Unlock()
+	for _, conn := range conns {
+		if _, err := conn.Write(jsonData); err != nil {
+			n.logger.Println("Error sending update:", err)
+		}
+	}
+}
+
+// SendTo sends an update to a single player, if we know its connection
+func (n *Node) SendTo(id string, data interface{}) {
+	jsonData, err := json.Marshal(data)
 	if err != nil {
-		fmt.Println("Error connecting to peer:", peerAddr, err)
+		n.logger.Println("Error encoding update:", err)
 		return
 	}
 
-	Mutex.Lock()
-	ActiveConnections[peerAddr] = conn
-	Mutex.Unlock()
+	n.mutex.Lock()
+	conn, ok := n.ids[id]
+	n.mutex.Unlock()
+	if ok {
+		if _, err := conn.Write(jsonData); err != nil {
+			n.logger.Println("Error sending update:", err)
+		}
+	}
+}
 
-	fmt.Println("Connected to peer:", peerAddr)
+// Close stops listening and closes every peer connection
+func (n *Node) Close() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
 
-	go handlePeerCommunication(conn)
+	n.closed = true
+	var err error
+	if n.listener != nil {
+		err = n.listener.Close()
+	}
+	for addr, conn := range n.conns {
+		conn.Close()
+		delete(n.conns, addr)
+	}
+	return err
 }
 
-func handlePeerCommunication(conn net.Conn) {
-    defer conn.Close()
+// Register announces this node's address so other peers find it
+func (n *Node) Register() {
+	if n.discovery == nil {
+		return
+	}
+	if err := n.discovery.Register(n.selfAddr); err != nil {
+		n.logger.Println("Error registering for discovery:", err)
+		return
+	}
+	n.mutex.Lock()
+	n.registered = true
+	n.mutex.Unlock()
+	n.logger.Println("Registered for discovery as:", n.selfAddr)
+}
 
-	peerAddr := conn.RemoteAddr().String()
-    buffer := make([]byte, 1024)
-    for {
-        n, err := conn.Read(buffer)
-        if err != nil {
-            fmt.Println("Peer disconnected:", peerAddr)
+// Deregister stops announcing this node, e.g. on exit. A node that never
+// registered, like a client of a dedicated server, has nothing to do.
+func (n *Node) Deregister() {
+	n.mutex.Lock()
+	registered := n.registered
+	n.registered = false
+	n.mutex.Unlock()
+	if n.discovery == nil || !registered {
+		return
+	}
+	if err := n.discovery.Deregister(n.selfAddr); err != nil {
+		n.logger.Println("Error deregistering from discovery:", err)
+		return
+	}
+	n.logger.Println("Deregistered from discovery:", n.selfAddr)
+}
 
-			// Remove the peer from active connections
-            Mutex.Lock()
-            delete(ActiveConnections, peerAddr)
-            Mutex.Unlock()
+// Peers returns the other peers discovery knows of, nil if it fails after
+// a few tries
+func (n *Node) Peers() []string {
+	if n.discovery == nil {
+		return nil
+	}
+	for retries := 0; retries < 3; retries++ {
+		found, err := n.discovery.Peers()
+		if err != nil {
+			n.logger.Println("Error reaching discovery (retrying)...", err)
+			time.Sleep(2 * time.Second)
+			continue
+		}
 
-			// Notify the game to remove the player
-			if GameInstance != nil {
-				GameInstance.RemovePlayerAfterDelay(peerAddr)
+		// Filter out self address
+		peers := []string{}
+		for _, peer := range found {
+			if peer != n.selfAddr {
+				peers = append(peers, peer)
 			}
-            return
-        }
-
-        var message map[string]interface{}
-        err = json.Unmarshal(buffer[:n], &message)
-        if err != nil {
-            fmt.Println("Error decoding message:", err)
-            continue
-        }
-
-        messageType, ok := message["type"].(string)
-        if !ok {
-            continue
-        }
-
-        // Handle movement updates
-        if messageType == "move" {
-            var moveMsg game.MovementMessage
-            json.Unmarshal(buffer[:n], &moveMsg)
-            if GameInstance != nil {
-                GameInstance.UpdatePlayerPosition(moveMsg)
-            }
-        }
-
-        // Handle shooting updates
-        if messageType == "bullet" {
-            var bulletMsg game.BulletMessage
-            json.Unmarshal(buffer[:n], &bulletMsg)
-            if GameInstance != nil {
-                GameInstance.AddBulletFromPeer(bulletMsg)
-            }
-        }
-    }
-}
-
-func SendUpdate(data interface{}) {
-    jsonData, err := json.Marshal(data)
-    if err != nil {
-        fmt.Println("Error encoding update:", err)
-        return
-    }
-
-    Mutex.Lock()
-    defer Mutex.Unlock()
-    for _, conn := range ActiveConnections {
-        _, err := conn.Write(jsonData)
-        if err != nil {
-            fmt.Println("Error sending update:", err)
-        }
-    }
-}
\ No newline at end of file
+		}
+		return peers
+	}
+	return nil
+}
+
+// HandleExit deregisters and closes the node on SIGINT (CTRL+C) or
+// SIGTERM, then exits
+func (n *Node) HandleExit() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		n.logger.Println("Shutting down...")
+
+		// Stop being discoverable
+		n.Deregister()
+
+		// Close all active connections
+		n.Close()
+		os.Exit(0)
+	}()
+}
@@ -3,6 +3,7 @@ package peer_test
 import (
 	"encoding/json"
 	"net"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,18 +11,19 @@ import (
 )
 // ** Test Peer Connection**
 func TestPeerConnection(t *testing.T) {
+	node := peer.NewNode()
+	defer node.Close()
+
 	mockServer := startMockPeerServer(t)
 	defer mockServer.Close()
 
 	peerAddr := mockServer.Listener.Addr().String()
-	go peer.ConnectToPeer(peerAddr)
+	go node.Connect(peerAddr)
 
 	// Allow some time for connection
 	time.Sleep(1 * time.Second)
 
-	peer.Mutex.Lock()
-	_, exists := peer.ActiveConnections[peerAddr]
-	peer.Mutex.Unlock()
+	exists := node.Connected(peerAddr)
 
 	if !exists {
 		t.Errorf("Expected connection to peer %s, but it was not established", peerAddr)
@@ -30,11 +32,14 @@ func TestPeerConnection(t *testing.T) {
 
 // ** Test Send and Receive Updates**
 func TestSendAndReceiveUpdates(t *testing.T) {
+	node := peer.NewNode()
+	defer node.Close()
+
 	mockServer := startMockPeerServer(t)
 	defer mockServer.Close()
 
 	peerAddr := mockServer.Listener.Addr().String()
-	go peer.ConnectToPeer(peerAddr)
+	go node.Connect(peerAddr)
 
 	time.Sleep(1 * time.Second) // Allow time for connection
 
@@ -43,7 +48,7 @@ func TestSendAndReceiveUpdates(t *testing.T) {
 		"data": "Hello, Peer!",
 	}
 
-	peer.SendUpdate(message)
+	node.Broadcast(message)
 
 	receivedMessage := <-mockServer.ReceivedMessages
 	if receivedMessage["type"] != "test_message" {
@@ -55,29 +60,32 @@ func TestDeregisterFromDiscovery(t *testing.T) {
 	mockDiscovery := startMockDiscoveryServer(t)
 	defer mockDiscovery.Close()
 
-	peer.DiscoveryServer = mockDiscovery.Listener.Addr().String()
-	peer.SelfAddr = "192.168.0.100:8080"
+	const selfAddr = "192.168.0.100:8080"
+	node := peer.NewNode(peer.WithDiscovery(mockDiscovery.Listener.Addr().String()), peer.WithSelfAddr(selfAddr))
 
 	// Register the peer
-	peer.RegisterWithDiscovery(peer.SelfAddr)
+	node.Register()
 
 	time.Sleep(1 * time.Second) // Allow time for registration
 
 	// Deregister the peer
-	peer.DeregisterFromDiscovery()
+	node.Deregister()
 
 	time.Sleep(1 * time.Second) // Allow time for deregistration
 
 	// Check if the peer was removed
-	peers := peer.GetPeers()
+	peers := node.Peers()
 	for _, p := range peers {
-		if p == peer.SelfAddr {
-			t.Errorf("Deregistered peer %s still found in registered peers", peer.SelfAddr)
+		if p == selfAddr {
+			t.Errorf("Deregistered peer %s still found in registered peers", selfAddr)
 		}
 	}
 }
 
 func TestMultiplePeersConnection(t *testing.T) {
+	node := peer.NewNode()
+	defer node.Close()
+
 	mockServer1 := startMockPeerServer(t)
 	mockServer2 := startMockPeerServer(t)
 	defer mockServer1.Close()
@@ -86,15 +94,13 @@ func TestMultiplePeersConnection(t *testing.T) {
 	peerAddr1 := mockServer1.Listener.Addr().String()
 	peerAddr2 := mockServer2.Listener.Addr().String()
 
-	go peer.ConnectToPeer(peerAddr1)
-	go peer.ConnectToPeer(peerAddr2)
+	go node.Connect(peerAddr1)
+	go node.Connect(peerAddr2)
 
 	time.Sleep(2 * time.Second) // Allow connections
 
-	peer.Mutex.Lock()
-	_, exists1 := peer.ActiveConnections[peerAddr1]
-	_, exists2 := peer.ActiveConnections[peerAddr2]
-	peer.Mutex.Unlock()
+	exists1 := node.Connected(peerAddr1)
+	exists2 := node.Connected(peerAddr2)
 
 	if !exists1 || !exists2 {
 		t.Errorf("One or more peers failed to connect: %v %v", exists1, exists2)
@@ -102,6 +108,9 @@ func TestMultiplePeersConnection(t *testing.T) {
 }
 
 func TestBroadcastUpdateToAllPeers(t *testing.T) {
+	node := peer.NewNode()
+	defer node.Close()
+
 	mockServer1 := startMockPeerServer(t)
 	mockServer2 := startMockPeerServer(t)
 	defer mockServer1.Close()
@@ -110,8 +119,8 @@ func TestBroadcastUpdateToAllPeers(t *testing.T) {
 	peerAddr1 := mockServer1.Listener.Addr().String()
 	peerAddr2 := mockServer2.Listener.Addr().String()
 
-	go peer.ConnectToPeer(peerAddr1)
-	go peer.ConnectToPeer(peerAddr2)
+	go node.Connect(peerAddr1)
+	go node.Connect(peerAddr2)
 
 	time.Sleep(2 * time.Second) // Allow connections
 
@@ -123,7 +132,7 @@ func TestBroadcastUpdateToAllPeers(t *testing.T) {
 		"angle": 90,
 	}
 
-	peer.SendUpdate(message)
+	node.Broadcast(message)
 
 	received1 := <-mockServer1.ReceivedMessages
 	received2 := <-mockServer2.ReceivedMessages
@@ -133,7 +142,66 @@ func TestBroadcastUpdateToAllPeers(t *testing.T) {
 	}
 }
 
+// ** Test Two Nodes In One Process**
+func TestNodesExchangeMessages(t *testing.T) {
+	handlerA := newRecordingHandler()
+	handlerB := newRecordingHandler()
+	nodeA := peer.NewNode(peer.WithListenAddr("127.0.0.1:0"), peer.WithHandler(handlerA))
+	nodeB := peer.NewNode(peer.WithHandler(handlerB))
+	if err := nodeA.Start(); err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	defer nodeA.Close()
+	defer nodeB.Close()
+
+	if err := nodeB.Connect(nodeA.Addr().String()); err != nil {
+		t.Fatalf("Failed to connect nodes: %v", err)
+	}
+	nodeB.Broadcast(map[string]interface{}{"type": "join", "id": "player_b"})
+
+	var message map[string]interface{}
+	select {
+	case data := <-handlerA.messages:
+		json.Unmarshal(data, &message)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Node A received nothing")
+	}
+	if message["id"] != "player_b" {
+		t.Errorf("Expected a join from player_b, got %v", message)
+	}
+
+	// Node A learned who talks on the connection, so it can answer directly
+	nodeA.SendTo("player_b", map[string]interface{}{"type": "welcome"})
+	select {
+	case data := <-handlerB.messages:
+		json.Unmarshal(data, &message)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Node B received nothing")
+	}
+	if message["type"] != "welcome" {
+		t.Errorf("Expected a welcome, got %v", message)
+	}
+
+	// Closing B tells A's handler that its player went away
+	nodeB.Close()
+	gone := map[string]bool{}
+	for len(gone) < 2 {
+		select {
+		case id := <-handlerA.left:
+			gone[id] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected node A to report the disconnect, got %v", gone)
+		}
+	}
+	if !gone["player_b"] {
+		t.Errorf("Expected player_b to be reported gone, got %v", gone)
+	}
+}
+
 func TestHighLoadMultiplePeers(t *testing.T) {
+	node := peer.NewNode()
+	defer node.Close()
+
 	const numPeers = 10
 	mockServers := make([]*mockServer, numPeers)
 	peerAddresses := make([]string, numPeers)
@@ -147,26 +215,27 @@ func TestHighLoadMultiplePeers(t *testing.T) {
 
 	// Connect to all peers
 	for _, addr := range peerAddresses {
-		go peer.ConnectToPeer(addr)
+		go node.Connect(addr)
 	}
 
 	time.Sleep(3 * time.Second) // Allow connections to stabilize
 
-	peer.Mutex.Lock()
 	for _, addr := range peerAddresses {
-		if _, exists := peer.ActiveConnections[addr]; !exists {
+		if !node.Connected(addr) {
 			t.Errorf("Peer %s did not connect properly", addr)
 		}
 	}
-	peer.Mutex.Unlock()
 }
 
 func TestEliminatedPlayersNotReceivingUpdates(t *testing.T) {
+	node := peer.NewNode()
+	defer node.Close()
+
 	mockServer := startMockPeerServer(t)
 	defer mockServer.Close()
 
 	peerAddr := mockServer.Listener.Addr().String()
-	go peer.ConnectToPeer(peerAddr)
+	go node.Connect(peerAddr)
 
 	time.Sleep(1 * time.Second) // Allow connection
 
@@ -175,7 +244,7 @@ func TestEliminatedPlayersNotReceivingUpdates(t *testing.T) {
 		"type":  "eliminate",
 		"id":    "test_player",
 	}
-	peer.SendUpdate(message)
+	node.Broadcast(message)
 
 	time.Sleep(1 * time.Second) // Allow time for processing
 
@@ -186,7 +255,7 @@ func TestEliminatedPlayersNotReceivingUpdates(t *testing.T) {
 		"x":    100,
 		"y":    100,
 	}
-	peer.SendUpdate(moveMessage)
+	node.Broadcast(moveMessage)
 
 	// Ensure the eliminated player did not receive the movement update
 	select {
@@ -206,8 +275,7 @@ func startMockPeerServer(t *testing.T) *mockServer {
 		decoder := json.NewDecoder(conn)
 		var message map[string]interface{}
 		if err := decoder.Decode(&message); err != nil {
-			t.Errorf("Error decoding peer message: %v", err)
-			return
+			return // The node closed without sending anything
 		}
 		server.ReceivedMessages <- message
 	})
@@ -217,6 +285,7 @@ func startMockPeerServer(t *testing.T) *mockServer {
 func startMockDiscoveryServer(t *testing.T) *mockServer {
 	server := newMockServer(t)
 	registeredPeers := make(map[string]bool) // Stores peer registrations
+	var mutex sync.Mutex
 
 	go server.ListenForRequests(func(conn net.Conn) {
 		decoder := json.NewDecoder(conn)
@@ -226,7 +295,7 @@ func startMockDiscoveryServer(t *testing.T) *mockServer {
 			return
 		}
 
-		peer.Mutex.Lock()
+		mutex.Lock()
 		if req.Type == "register" {
 			registeredPeers[req.Addr] = true
 		} else if req.Type == "get_peers" {
@@ -235,15 +304,28 @@ func startMockDiscoveryServer(t *testing.T) *mockServer {
 				peerList = append(peerList, addr)
 			}
 			resp := peer.Response{Peers: peerList}
-			peer.Mutex.Unlock()
+			mutex.Unlock()
 			json.NewEncoder(conn).Encode(resp)
 			return
 		}
-		peer.Mutex.Unlock()
+		mutex.Unlock()
 	})
 	return server
 }
 
+// ** Recording Handler**
+type recordingHandler struct {
+	messages chan []byte
+	left     chan string
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{messages: make(chan []byte, 10), left: make(chan string, 10)}
+}
+
+func (h *recordingHandler) Deliver(data []byte)  { h.messages <- data }
+func (h *recordingHandler) Disconnect(id string) { h.left <- id }
+
 // ** Mock Server Helper**
 type mockServer struct {
 	Listener        net.Listener
@@ -274,4 +356,93 @@ func (s *mockServer) ListenForRequests(handler func(conn net.Conn)) {
 
 func (s *mockServer) Close() {
 	s.Listener.Close()
-}
\ No newline at end of file
+}
+// ** Test Simulated Latency**
+func TestSimulatedLatency(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := peer.WrapConn(client, peer.Conditions{Latency: 100 * time.Millisecond})
+	defer conn.Close()
+
+	start := time.Now()
+	conn.Write([]byte(`{"type":"move"}`))
+
+	var message map[string]interface{}
+	if err := json.NewDecoder(server).Decode(&message); err != nil {
+		t.Fatalf("Error decoding message: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected at least 100ms of latency, got %v", elapsed)
+	}
+}
+
+// ** Test Simulated Loss And Reordering**
+func TestSimulatedLossAndReorder(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	// Everything is lost
+	lossy := peer.WrapConn(client, peer.Conditions{Loss: 1, Seed: 1})
+	lossy.Write([]byte(`{"type":"move"}`))
+	server.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if n, _ := server.Read(make([]byte, 64)); n != 0 {
+		t.Errorf("Expected the message to be dropped")
+	}
+	lossy.Close()
+
+	// Half the messages are held back, so later ones overtake them
+	client, server = net.Pipe()
+	defer server.Close()
+	conn := peer.WrapConn(client, peer.Conditions{Latency: 5 * time.Millisecond, Reorder: 0.5, Seed: 42})
+	defer conn.Close()
+
+	const count = 20
+	go func() {
+		for i := 0; i < count; i++ {
+			data, _ := json.Marshal(map[string]int{"seq": i})
+			conn.Write(data)
+		}
+	}()
+
+	decoder := json.NewDecoder(server)
+	outOfOrder := false
+	last := -1
+	for i := 0; i < count; i++ {
+		var message map[string]int
+		if err := decoder.Decode(&message); err != nil {
+			t.Fatalf("Error decoding message: %v", err)
+		}
+		if message["seq"] < last {
+			outOfOrder = true
+		}
+		last = message["seq"]
+	}
+	if !outOfOrder {
+		t.Errorf("Expected some messages to arrive out of order")
+	}
+}
+
+// ** Test Simulated Link Overflow**
+func TestFullSimulatedLinkDropsAndCloses(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := peer.WrapConn(client, peer.Conditions{Latency: time.Hour, Bandwidth: 1})
+
+	// Nothing drains the link, so past the queue writes are dropped
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < peer.SimQueue+100; i++ {
+			conn.Write([]byte(`{"type":"move"}`))
+		}
+		conn.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected writes and Close not to block on a full link")
+	}
+	if dropped := peer.Dropped(conn); dropped < 99 {
+		t.Errorf("Expected the overflow to be dropped, got %d", dropped)
+	}
+}